@@ -0,0 +1,39 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+type SimpleAppContainer struct {
+	// Name of the container. Must be unique within the pod.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// Container image name.
+	Image string `json:"image"`
+	// Entrypoint array. The image's ENTRYPOINT is used if this is not
+	// provided.
+	Command []string `json:"command,omitempty"`
+	// Arguments to the entrypoint. The image's CMD is used if this is not
+	// provided.
+	Args []string `json:"args,omitempty"`
+	// Environment variables to set in container.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// List of ports to expose from the container.
+	Ports []corev1.ContainerPort `json:"ports,omitempty"`
+	// Compute resources required by this container.
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// Volumes of the SimpleApp to mount in this container, referenced by one
+	// of their mount paths.
+	Volumes []SimpleAppContainerVolume `json:"volumes,omitempty"`
+	// If set to Always, the sidecar is run as a native sidecar, started before
+	// init containers and the application container.
+	// +kubebuilder:validation:Enum=Always
+	RestartPolicy *corev1.ContainerRestartPolicy `json:"restartPolicy,omitempty"`
+}
+
+// References a volume of the SimpleApp by its mountPath or that of one of its
+// additional mounts
+type SimpleAppContainerVolume struct {
+	// mountPath of a volume declared in volumes.
+	MountPath string `json:"mountPath"`
+}
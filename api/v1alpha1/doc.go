@@ -0,0 +1,6 @@
+// Package v1alpha1 contains the v1alpha1 API of SimpleApps, which the
+// controller reads. The CustomResourceDefinition in simpleapp.yml is
+// generated from these types by cmd/crdgen.
+// +kubebuilder:object:generate=true
+// +groupName=apps.raulpedroche.es
+package v1alpha1
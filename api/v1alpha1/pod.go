@@ -0,0 +1,73 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+type SimpleAppSecurityContext struct {
+	// Security profile to start from. Defaults to the controller's profile.
+	// +kubebuilder:validation:Enum=restricted;none
+	Profile string `json:"profile,omitempty"`
+	// The UID to run the entrypoint of the container process.
+	RunAsUser *int64 `json:"runAsUser,omitempty"`
+	// The GID to run the entrypoint of the container process.
+	RunAsGroup *int64 `json:"runAsGroup,omitempty"`
+	// Indicates that the container must run as a non-root user.
+	RunAsNonRoot *bool `json:"runAsNonRoot,omitempty"`
+	// A special supplemental group that applies to all volumes of the pod.
+	FSGroup *int64 `json:"fsGroup,omitempty"`
+	// Whether the container has a read-only root filesystem.
+	ReadOnlyRootFilesystem *bool `json:"readOnlyRootFilesystem,omitempty"`
+	// Whether a process can gain more privileges than its parent process.
+	AllowPrivilegeEscalation *bool `json:"allowPrivilegeEscalation,omitempty"`
+	// The capabilities to add/drop when running the container. With the
+	// restricted profile, ALL are dropped and only add is used.
+	Capabilities *corev1.Capabilities `json:"capabilities,omitempty"`
+	// The seccomp options to use by the pod.
+	SeccompProfile *corev1.SeccompProfile `json:"seccompProfile,omitempty"`
+}
+
+type SimpleAppScheduling struct {
+	// Labels a node must have for the pods to be scheduled on it.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Tolerations of the pods.
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// Node affinity, pod affinity and pod anti-affinity rules, as in a Pod
+	// spec.
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// Name of the PriorityClass of the pods.
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// Topology spread constraints, as in a Pod spec.
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	// Shorthand to spread the pods of this SimpleApp across zones or hosts.
+	// +kubebuilder:validation:Enum=zone;host
+	Spread string `json:"spread,omitempty"`
+}
+
+type SimpleAppPod struct {
+	// DNS policy of the pods. Defaults to ClusterFirst. None requires
+	// dnsConfig.
+	// +kubebuilder:validation:Enum=ClusterFirst;ClusterFirstWithHostNet;Default;None
+	DNSPolicy corev1.DNSPolicy `json:"dnsPolicy,omitempty"`
+	// DNS parameters merged with those generated from dnsPolicy.
+	DNSConfig *corev1.PodDNSConfig `json:"dnsConfig,omitempty"`
+	// Entries added to the /etc/hosts file of the pods.
+	HostAliases []corev1.HostAlias `json:"hostAliases,omitempty"`
+	// Name of the RuntimeClass used to run the pods, such as gvisor.
+	RuntimeClassName *string `json:"runtimeClassName,omitempty"`
+	// Share a single process namespace between all of the containers in a
+	// pod. Defaults to false.
+	ShareProcessNamespace *bool `json:"shareProcessNamespace,omitempty"`
+	// Use the host's network namespace. Only allowed if the controller is run
+	// with -allow-host-network. Defaults to false.
+	HostNetwork bool `json:"hostNetwork,omitempty"`
+}
+
+type SimpleAppLifecycle struct {
+	// Handler run right after the container is created, with exec, httpGet or
+	// sleep as in a Pod spec.
+	PostStart *corev1.LifecycleHandler `json:"postStart,omitempty"`
+	// Handler run before the container is terminated, with exec, httpGet or
+	// sleep as in a Pod spec. Cannot be used together with drainSeconds.
+	PreStop *corev1.LifecycleHandler `json:"preStop,omitempty"`
+}
@@ -0,0 +1,237 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// A Simple App runs a single application container and exposes its ports
+// through Services.
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+// +kubebuilder:resource:shortName=sa,categories=all
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.status.image`
+// +kubebuilder:printcolumn:name="Desired",type=integer,JSONPath=`.status.desiredReplicas`
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.readyReplicas`
+// +kubebuilder:printcolumn:name="Service",type=string,JSONPath=`.status.serviceType`
+// +kubebuilder:printcolumn:name="Address",type=string,JSONPath=`.status.address`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type SimpleApp struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SimpleAppSpec   `json:"spec,omitempty"`
+	Status SimpleAppStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+type SimpleAppList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []SimpleApp `json:"items"`
+}
+
+// Describes the configuration of a Simple App. These contain a Deployment
+// where each Pod has a single container and exposes one or more ports.
+// +kubebuilder:validation:XValidation:rule="!has(self.replicas) || self.replicas == 1 || !has(self.workloadType) || self.workloadType in ['Deployment', 'StatefulSet']",message="replicas is only supported with workloadType Deployment or StatefulSet"
+type SimpleAppSpec struct {
+	// Kind of workload to create. A StatefulSet also gets a headless Service
+	// named <name>-headless. Job and CronJob run the container to completion;
+	// a changed Job is deleted and run again. A DaemonSet runs one pod on each
+	// eligible node. Defaults to Deployment.
+	// +kubebuilder:validation:Enum=Deployment;StatefulSet;Job;CronJob;DaemonSet
+	// +kubebuilder:default=Deployment
+	WorkloadType string `json:"workloadType,omitempty"`
+	// Container image name.
+	Image string `json:"image"`
+	// Number of desired pods. Only Deployments and StatefulSets can have
+	// other than 1, so the scale subresource only applies to them.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=1
+	Replicas *int32 `json:"replicas,omitempty"`
+	// Stops the app without deleting it or its Services. Deployments and
+	// StatefulSets are scaled to zero and get their replicas back on resume,
+	// Jobs and CronJobs are suspended. Not supported with workloadType
+	// DaemonSet.
+	Suspend bool `json:"suspend,omitempty"`
+	// How a Deployment is suspended. ScaleToZero, the default, stops its
+	// pods; Paused keeps them running but stops rolling out changes.
+	// +kubebuilder:validation:Enum=ScaleToZero;Paused
+	SuspendMode string `json:"suspendMode,omitempty"`
+	// Type of the service to create. Must be ClusterIP, NodePort, or
+	// LoadBalancer. Defaults to ClusterIP.
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	// +kubebuilder:default=ClusterIP
+	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
+	// Options for the Service exposing the ports. No Service is created when
+	// there are no ports.
+	Service *SimpleAppService `json:"service,omitempty"`
+	// Additional Services, each named <name>-<service name> and exposing some
+	// or all of the ports.
+	Services []SimpleAppNamedService `json:"services,omitempty"`
+
+	// Additional labels of the pods. The labels used by selectors cannot be
+	// overridden.
+	PodLabels map[string]string `json:"podLabels,omitempty"`
+	// Annotations of the pods.
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+	// Time a restart was requested. Setting or changing it restarts the pods,
+	// like kubectl rollout restart does for the workload. A changed Job is
+	// run again.
+	RestartedAt *metav1.Time `json:"restartedAt,omitempty"`
+	// Keys of labels of the SimpleApp to copy to the generated workload,
+	// Services and pods. The labels used by selectors cannot be overridden.
+	PropagateLabels []string `json:"propagateLabels,omitempty"`
+	// Value of the app.kubernetes.io/component label of generated objects.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?$`
+	Component string `json:"component,omitempty"`
+	// Value of the app.kubernetes.io/part-of label of generated objects.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?$`
+	PartOf string `json:"partOf,omitempty"`
+	// Workloads created before the app.kubernetes.io labels were introduced
+	// keep selecting their pods by the app label. If true, such a workload is
+	// deleted leaving its pods running and created again selecting
+	// app.kubernetes.io/name and app.kubernetes.io/instance, adopting the
+	// existing pods.
+	MigrateSelector bool `json:"migrateSelector,omitempty"`
+	// List of ports to expose from the container.
+	Ports []SimpleAppPort `json:"ports,omitempty"`
+	// Environment variables to set in container.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// List of volumes mounted by the container belonging to the pod, at most
+	// 64.
+	// +kubebuilder:validation:MaxItems=64
+	Volumes []SimpleAppVolume `json:"volumes,omitempty"`
+
+	// List of containers run to completion, in order, before the application
+	// container starts.
+	InitContainers []SimpleAppContainer `json:"initContainers,omitempty"`
+	// List of additional containers running alongside the application
+	// container.
+	Sidecars []SimpleAppContainer `json:"sidecars,omitempty"`
+
+	// Security options for the pod and its container. Unless profile is set
+	// to none, the controller default profile (restricted) makes pods
+	// compliant with the "restricted" Pod Security Standard. Fields set here
+	// override the profile.
+	SecurityContext *SimpleAppSecurityContext `json:"securityContext,omitempty"`
+	// Placement options for the pods.
+	Scheduling *SimpleAppScheduling `json:"scheduling,omitempty"`
+	// DNS, networking and runtime options of the pods.
+	Pod *SimpleAppPod `json:"pod,omitempty"`
+
+	// Hooks run by the application container after it starts and before it is
+	// stopped.
+	Lifecycle *SimpleAppLifecycle `json:"lifecycle,omitempty"`
+	// Seconds the pods are given to stop before they are killed. Defaults to
+	// 30, or to drainSeconds plus 30 when drainSeconds is set.
+	// +kubebuilder:validation:Minimum=0
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+	// Seconds the application container waits before it is stopped, so that
+	// it stops receiving traffic before it shuts down.
+	// +kubebuilder:validation:Minimum=1
+	DrainSeconds *int32 `json:"drainSeconds,omitempty"`
+
+	// The strategy to use to replace existing pods with new ones. Only used
+	// with workloadType Deployment and DaemonSet.
+	Strategy *SimpleAppStrategy `json:"strategy,omitempty"`
+	// Minimum number of seconds for which a newly created pod should be ready
+	// without any of its container crashing, for it to be considered
+	// available. Defaults to 0.
+	// +kubebuilder:validation:Minimum=0
+	MinReadySeconds int32 `json:"minReadySeconds,omitempty"`
+	// The maximum time in seconds for a deployment to make progress before it
+	// is considered to be failed. Defaults to 600. Only used with workloadType
+	// Deployment.
+	// +kubebuilder:validation:Minimum=1
+	ProgressDeadlineSeconds *int32 `json:"progressDeadlineSeconds,omitempty"`
+	// The number of old ReplicaSets to retain to allow rollback. Defaults to
+	// 10.
+	// +kubebuilder:validation:Minimum=0
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+
+	// Options for workloadType Job and CronJob.
+	Job *SimpleAppJob `json:"job,omitempty"`
+}
+
+type SimpleAppPort struct {
+	// If specified, this must be an IANA_SVC_NAME and unique within the pod.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name,omitempty"`
+	// Port exposed by the Services of the SimpleApp. Must be a valid port
+	// number, 0 < x < 65536.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	HostPort int32 `json:"hostPort"`
+	// Number of port to expose on the pod's IP address. This must be a valid
+	// port number, 0 < x < 65536.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	ContainerPort int32 `json:"containerPort"`
+	// Protocol for port. Must be UDP, TCP, or SCTP. Defaults to "TCP".
+	// +kubebuilder:validation:Enum=SCTP;TCP;UDP
+	// +kubebuilder:default=TCP
+	Protocol corev1.Protocol `json:"protocol,omitempty"`
+	// Port on each node on which this port is exposed by Services of type
+	// NodePort or LoadBalancer. Allocated by the system if not specified.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	NodePort int32 `json:"nodePort,omitempty"`
+}
+
+type SimpleAppService struct {
+	// Whether to create the Service. Defaults to true.
+	// +kubebuilder:default=true
+	Enabled *bool `json:"enabled,omitempty"`
+	// Set to None for a headless Service. Requires Service type ClusterIP.
+	// +kubebuilder:validation:Enum=None
+	ClusterIP string `json:"clusterIP,omitempty"`
+	// Must be ClientIP or None. Defaults to None.
+	// +kubebuilder:validation:Enum=ClientIP;None
+	SessionAffinity corev1.ServiceAffinity `json:"sessionAffinity,omitempty"`
+	// Seconds of ClientIP session stickiness. Defaults to 10800.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=86400
+	SessionAffinityTimeoutSeconds *int32 `json:"sessionAffinityTimeoutSeconds,omitempty"`
+	// How external traffic is routed to endpoints. Requires Service type
+	// NodePort or LoadBalancer. Defaults to Cluster.
+	// +kubebuilder:validation:Enum=Cluster;Local
+	ExternalTrafficPolicy corev1.ServiceExternalTrafficPolicy `json:"externalTrafficPolicy,omitempty"`
+	// How cluster internal traffic is routed to endpoints. Defaults to
+	// Cluster.
+	// +kubebuilder:validation:Enum=Cluster;Local
+	InternalTrafficPolicy *corev1.ServiceInternalTrafficPolicy `json:"internalTrafficPolicy,omitempty"`
+	// Class of the load balancer implementation. Requires Service type
+	// LoadBalancer.
+	LoadBalancerClass *string `json:"loadBalancerClass,omitempty"`
+	// Client CIDRs allowed to access the load balancer. Requires Service type
+	// LoadBalancer.
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+	// Annotations of the Service, for example to configure cloud load
+	// balancers. Annotations set by others are kept.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// An additional Service named <name>-<service name>, exposing some or all
+// ports of the SimpleApp
+type SimpleAppNamedService struct {
+	// Name of the Service, appended to the SimpleApp name. headless is
+	// reserved.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// Type of the Service. Must be ClusterIP, NodePort, or LoadBalancer.
+	// Defaults to ClusterIP.
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	// +kubebuilder:default=ClusterIP
+	Type corev1.ServiceType `json:"type,omitempty"`
+	// Names of the ports to expose. All ports are exposed if not specified.
+	Ports []string `json:"ports,omitempty"`
+
+	SimpleAppService `json:",inline"`
+}
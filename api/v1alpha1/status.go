@@ -0,0 +1,51 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Observed state of the Simple App, maintained by the controller.
+type SimpleAppStatus struct {
+	// Image of the application container in the workload.
+	Image string `json:"image,omitempty"`
+	// Number of pods the workload should run. Not set for Job and CronJob.
+	DesiredReplicas int32 `json:"desiredReplicas,omitempty"`
+	// Number of pods of the workload, whatever their spec. Reported by the
+	// scale subresource.
+	Replicas int32 `json:"replicas,omitempty"`
+	// Number of pods of the workload that are ready.
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+	// Label selector of the pods of the workload, in the string form used by
+	// the scale subresource and the HorizontalPodAutoscaler.
+	Selector string `json:"selector,omitempty"`
+	// Type of the Service named after the SimpleApp, if there is one.
+	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
+	// Hostnames or IPs of the load balancer of a LoadBalancer Service,
+	// separated by commas.
+	Address string `json:"address,omitempty"`
+	// Time of the last restart requested through spec.restartedAt, once
+	// rolled out to the workload.
+	LastRestartedAt *metav1.Time `json:"lastRestartedAt,omitempty"`
+	// Latest observations of the SimpleApp. Ready is true once every pod of
+	// the workload runs the current spec and is ready, or for Job and CronJob
+	// while the last run did not fail. Suspended is true while spec.suspend
+	// is in effect.
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Outcome of the most recent Job run, for Job and CronJob workloads.
+	LastRun *SimpleAppRunStatus `json:"lastRun,omitempty"`
+}
+
+type SimpleAppRunStatus struct {
+	JobName        string       `json:"jobName"`
+	StartTime      *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// +kubebuilder:validation:Enum=Running;Succeeded;Failed;Unknown
+	Result  string `json:"result"`
+	Message string `json:"message,omitempty"`
+	// Hash of the spec the Job was created from, so that it is not run again
+	// once deleted.
+	SpecHash string `json:"specHash,omitempty"`
+}
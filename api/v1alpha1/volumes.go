@@ -0,0 +1,143 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// A volume of the pod and its mounts in the application container. Exactly
+// one volume source must be set.
+// +kubebuilder:validation:XValidation:rule="[has(self.emptyDir), has(self.configMap), has(self.persistentVolumeClaim), has(self.secret), has(self.csi), has(self.volumeClaimTemplate), has(self.downwardAPI), has(self.projected), has(self.hostPath), has(self.ephemeral), has(self.image)].filter(isSet, isSet).size() == 1",message="volume must have exactly one type"
+type SimpleAppVolume struct {
+	// Path within the container at which the volume should be mounted.
+	// +kubebuilder:validation:Pattern=`^(/[^/]+)+$`
+	MountPath string `json:"mountPath"`
+	// emptyDir represents a temporary directory that shares a pod's lifetime.
+	EmptyDir *SimpleAppVolumeEmptyDir `json:"emptyDir,omitempty"`
+	// Adapts a ConfigMap into a volume.
+	ConfigMap *SimpleAppVolumeConfigMapOrSecret `json:"configMap,omitempty"`
+	// Represents a reference to a PersistentVolumeClaim in the same Namespace.
+	PersistentVolumeClaim *SimpleAppVolumePersistentVolumeClaim `json:"persistentVolumeClaim,omitempty"`
+	// Represents a secret that should populate this volume.
+	Secret *SimpleAppVolumeConfigMapOrSecret `json:"secret,omitempty"`
+	// (Container Storage Interface) represents ephemeral storage that is
+	// handled by certain external CSI drivers.
+	CSI *corev1.CSIVolumeSource `json:"csi,omitempty"`
+	// Requests a PersistentVolumeClaim for each replica. Only allowed with
+	// workloadType StatefulSet. Claims are kept when the SimpleApp is deleted,
+	// and cannot be changed once created.
+	VolumeClaimTemplate *SimpleAppVolumeClaimTemplate `json:"volumeClaimTemplate,omitempty"`
+	// Exposes pod metadata and container resources as files, in the format of
+	// a Pod downwardAPI volume.
+	DownwardAPI *corev1.DownwardAPIVolumeSource `json:"downwardAPI,omitempty"`
+	// Combines ConfigMaps, Secrets, downward API information and service
+	// account tokens in a single directory, in the format of a Pod projected
+	// volume.
+	Projected *corev1.ProjectedVolumeSource `json:"projected,omitempty"`
+	// Mounts a file or directory from the host node. Only allowed if the
+	// controller is run with -allow-host-path.
+	HostPath *corev1.HostPathVolumeSource `json:"hostPath,omitempty"`
+	// Provisions a PersistentVolumeClaim for each pod, deleted along with it.
+	Ephemeral *SimpleAppVolumeClaimTemplate `json:"ephemeral,omitempty"`
+	// Mounts the contents of an OCI image or artifact.
+	Image *corev1.ImageVolumeSource `json:"image,omitempty"`
+	// Path within the volume from which the container's volume should be
+	// mounted. Defaults to the volume's root.
+	SubPath string `json:"subPath,omitempty"`
+	// Like subPath, but with $(VAR_NAME) references expanded using the
+	// container's environment. Mutually exclusive with subPath.
+	SubPathExpr string `json:"subPathExpr,omitempty"`
+	// Mounted read-only if true, read-write otherwise. Defaults to false.
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// How mounts are propagated from the host to the container and the other
+	// way around. Defaults to None.
+	// +kubebuilder:validation:Enum=None;HostToContainer;Bidirectional
+	MountPropagation *corev1.MountPropagationMode `json:"mountPropagation,omitempty"`
+	// Additional mounts of the same volume at other paths in the container.
+	Mounts []SimpleAppVolumeMount `json:"mounts,omitempty"`
+}
+
+// An additional mount of the same volume source at another path
+type SimpleAppVolumeMount struct {
+	// Path within the container at which the volume should be mounted.
+	// +kubebuilder:validation:Pattern=`^(/[^/]+)+$`
+	MountPath string `json:"mountPath"`
+	// Path within the volume from which the container's volume should be
+	// mounted. Defaults to the volume's root.
+	SubPath string `json:"subPath,omitempty"`
+	// Like subPath, but with $(VAR_NAME) references expanded using the
+	// container's environment. Mutually exclusive with subPath.
+	SubPathExpr string `json:"subPathExpr,omitempty"`
+	// Mounted read-only if true, read-write otherwise. Defaults to false.
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// How mounts are propagated from the host to the container and the other
+	// way around. Defaults to None.
+	// +kubebuilder:validation:Enum=None;HostToContainer;Bidirectional
+	MountPropagation *corev1.MountPropagationMode `json:"mountPropagation,omitempty"`
+}
+
+type SimpleAppVolumeEmptyDir struct {
+	// medium represents what type of storage medium should back this
+	// directory. The default is "" which means to use the node's default
+	// medium. Must be an empty string (default) or Memory.
+	Medium corev1.StorageMedium `json:"medium,omitempty"`
+	// sizeLimit is the total amount of local storage required for this
+	// EmptyDir volume.
+	SizeLimit *resource.Quantity `json:"sizeLimit,omitempty"`
+}
+
+type SimpleAppVolumeConfigMapOrSecret struct {
+	// Mode bits used to set permissions on created files by default. Must be
+	// an octal value between 0000 and 0777.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=511
+	DefaultMode *int32 `json:"defaultMode,omitempty"`
+	// If unspecified, each key-value pair in the Data field of the referenced
+	// object will be projected into the volume as a file whose name is the key
+	// and content is the value. If specified, the listed keys will be
+	// projected into the specified paths, and unlisted keys will not be
+	// present.
+	Items []corev1.KeyToPath `json:"items,omitempty"`
+	// Name of the referent.
+	Name string `json:"name"`
+	// Specify whether the referenced object or its values must be defined.
+	// +kubebuilder:default=false
+	Optional *bool `json:"optional,omitempty"`
+}
+
+type SimpleAppVolumePersistentVolumeClaim struct {
+	// The name of a PersistentVolumeClaim in the same namespace
+	ClaimName string `json:"claimName"`
+	// Will force the ReadOnly setting in VolumeMounts. Default false
+	// +kubebuilder:default=false
+	ReadOnly *bool `json:"readOnly,omitempty"`
+	// Provisions the claim instead of expecting it to exist. Its size can be
+	// grown later, other settings cannot be changed once it is created.
+	Create *SimpleAppPersistentVolumeClaimCreate `json:"create,omitempty"`
+}
+
+// A PersistentVolumeClaim provisioned by the controller. It is kept when the
+// SimpleApp is deleted unless deletePolicy is Delete.
+type SimpleAppPersistentVolumeClaimCreate struct {
+	SimpleAppVolumeClaimTemplate `json:",inline"`
+	// Whether the volume is formatted with a filesystem or used as a raw block
+	// device. Defaults to Filesystem.
+	// +kubebuilder:validation:Enum=Filesystem;Block
+	VolumeMode *corev1.PersistentVolumeMode `json:"volumeMode,omitempty"`
+	// What happens to the claim when it is no longer used by the SimpleApp.
+	// Retain, the default, keeps it and its data; Delete removes it.
+	// +kubebuilder:validation:Enum=Retain;Delete
+	DeletePolicy string `json:"deletePolicy,omitempty"`
+}
+
+type SimpleAppVolumeClaimTemplate struct {
+	// Name of the StorageClass required by the claim. Uses the default class
+	// if not set.
+	StorageClassName *string `json:"storageClassName,omitempty"`
+	// Storage size requested for each claim. Growing it expands a created
+	// claim if its StorageClass allows it.
+	Size resource.Quantity `json:"size"`
+	// Desired access modes of the volume. Defaults to ReadWriteOnce.
+	// +kubebuilder:validation:items:Enum=ReadWriteOnce;ReadOnlyMany;ReadWriteMany;ReadWriteOncePod
+	AccessModes []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+}
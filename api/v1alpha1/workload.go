@@ -0,0 +1,59 @@
+package v1alpha1
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+type SimpleAppStrategy struct {
+	// Type of update. Can be Recreate (Deployment only), OnDelete (DaemonSet
+	// only) or RollingUpdate. Default is RollingUpdate.
+	// +kubebuilder:validation:Enum=Recreate;OnDelete;RollingUpdate
+	Type string `json:"type,omitempty"`
+	// The maximum number of pods that can be scheduled above the desired
+	// number of pods. Value can be an absolute number or a percentage. Only
+	// allowed with RollingUpdate. Defaults to 25% for Deployments and 0 for
+	// DaemonSets.
+	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+	// The maximum number of pods that can be unavailable during the update.
+	// Value can be an absolute number or a percentage. Only allowed with
+	// RollingUpdate. Defaults to 25% for Deployments and 1 for DaemonSets.
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+type SimpleAppJob struct {
+	// The schedule in Cron format. Required with CronJob workloads, not
+	// allowed otherwise.
+	Schedule string `json:"schedule,omitempty"`
+	// The time zone name for the schedule. Defaults to the time zone of the
+	// controller manager.
+	TimeZone *string `json:"timeZone,omitempty"`
+	// How to treat concurrent executions of a CronJob. Defaults to Allow.
+	// +kubebuilder:validation:Enum=Allow;Forbid;Replace
+	ConcurrencyPolicy batchv1.ConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+	// Deadline in seconds for starting a CronJob run if it misses its
+	// scheduled time.
+	// +kubebuilder:validation:Minimum=0
+	StartingDeadlineSeconds *int64 `json:"startingDeadlineSeconds,omitempty"`
+	// The number of successful finished Jobs to retain. Defaults to 3.
+	// +kubebuilder:validation:Minimum=0
+	SuccessfulJobsHistoryLimit *int32 `json:"successfulJobsHistoryLimit,omitempty"`
+	// The number of failed finished Jobs to retain. Defaults to 1.
+	// +kubebuilder:validation:Minimum=0
+	FailedJobsHistoryLimit *int32 `json:"failedJobsHistoryLimit,omitempty"`
+	// Number of retries before marking a Job failed. Defaults to 6.
+	// +kubebuilder:validation:Minimum=0
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+	// Duration in seconds a Job may be active before the system tries to
+	// terminate it.
+	// +kubebuilder:validation:Minimum=1
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+	// Seconds after a Job finishes before it is deleted automatically. A
+	// deleted Job is not run again until its spec changes.
+	// +kubebuilder:validation:Minimum=0
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+	// Restart policy of the Job pods. Defaults to OnFailure.
+	// +kubebuilder:validation:Enum=OnFailure;Never
+	RestartPolicy corev1.RestartPolicy `json:"restartPolicy,omitempty"`
+}
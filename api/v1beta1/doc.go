@@ -0,0 +1,5 @@
+// Package v1beta1 contains the v1beta1 API of SimpleApps, the version they
+// are stored as. It groups the workload options and moves the Service type
+// into the service block; everything else is shared with v1alpha1.
+// +groupName=apps.raulpedroche.es
+package v1beta1
@@ -0,0 +1,195 @@
+package v1beta1
+
+import (
+	"github.com/pecio/simpleapp/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// A Simple App runs a single application container and exposes its ports
+// through Services.
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.workload.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+// +kubebuilder:resource:shortName=sa,categories=all
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.status.image`
+// +kubebuilder:printcolumn:name="Desired",type=integer,JSONPath=`.status.desiredReplicas`
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.readyReplicas`
+// +kubebuilder:printcolumn:name="Service",type=string,JSONPath=`.status.serviceType`
+// +kubebuilder:printcolumn:name="Address",type=string,JSONPath=`.status.address`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:storageversion
+type SimpleApp struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SimpleAppSpec            `json:"spec,omitempty"`
+	Status v1alpha1.SimpleAppStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+type SimpleAppList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []SimpleApp `json:"items"`
+}
+
+// Describes the configuration of a Simple App. These contain a Deployment
+// where each Pod has a single container and exposes one or more ports.
+type SimpleAppSpec struct {
+	// Container image name.
+	Image string `json:"image"`
+	// Kind of workload running the pods and its rollout options.
+	Workload *SimpleAppWorkload `json:"workload,omitempty"`
+	// Stops the app without deleting it or its Services. Deployments and
+	// StatefulSets are scaled to zero and get their replicas back on resume,
+	// Jobs and CronJobs are suspended. Not supported with workload type
+	// DaemonSet.
+	Suspend bool `json:"suspend,omitempty"`
+	// How a Deployment is suspended. ScaleToZero, the default, stops its
+	// pods; Paused keeps them running but stops rolling out changes.
+	// +kubebuilder:validation:Enum=ScaleToZero;Paused
+	SuspendMode string `json:"suspendMode,omitempty"`
+	// Options for the Service exposing the ports. No Service is created when
+	// there are no ports.
+	Service *SimpleAppService `json:"service,omitempty"`
+	// Additional Services, each named <name>-<service name> and exposing some
+	// or all of the ports.
+	Services []v1alpha1.SimpleAppNamedService `json:"services,omitempty"`
+	// List of ports to expose from the container.
+	Ports []SimpleAppPort `json:"ports,omitempty"`
+	// Environment variables to set in container.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// List of volumes mounted by the container belonging to the pod, at most
+	// 64.
+	// +kubebuilder:validation:MaxItems=64
+	Volumes []v1alpha1.SimpleAppVolume `json:"volumes,omitempty"`
+
+	// List of containers run to completion, in order, before the application
+	// container starts.
+	InitContainers []v1alpha1.SimpleAppContainer `json:"initContainers,omitempty"`
+	// List of additional containers running alongside the application
+	// container.
+	Sidecars []v1alpha1.SimpleAppContainer `json:"sidecars,omitempty"`
+
+	// Security options for the pod and its container. Unless profile is set
+	// to none, the controller default profile (restricted) makes pods
+	// compliant with the "restricted" Pod Security Standard. Fields set here
+	// override the profile.
+	SecurityContext *v1alpha1.SimpleAppSecurityContext `json:"securityContext,omitempty"`
+	// Placement options for the pods.
+	Scheduling *v1alpha1.SimpleAppScheduling `json:"scheduling,omitempty"`
+	// DNS, networking and runtime options of the pods.
+	Pod *v1alpha1.SimpleAppPod `json:"pod,omitempty"`
+
+	// Additional labels of the pods. The labels used by selectors cannot be
+	// overridden.
+	PodLabels map[string]string `json:"podLabels,omitempty"`
+	// Annotations of the pods.
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+	// Time a restart was requested. Setting or changing it restarts the pods,
+	// like kubectl rollout restart does for the workload. A changed Job is
+	// run again.
+	RestartedAt *metav1.Time `json:"restartedAt,omitempty"`
+	// Keys of labels of the SimpleApp to copy to the generated workload,
+	// Services and pods. The labels used by selectors cannot be overridden.
+	PropagateLabels []string `json:"propagateLabels,omitempty"`
+	// Value of the app.kubernetes.io/component label of generated objects.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?$`
+	Component string `json:"component,omitempty"`
+	// Value of the app.kubernetes.io/part-of label of generated objects.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?$`
+	PartOf string `json:"partOf,omitempty"`
+	// Workloads created before the app.kubernetes.io labels were introduced
+	// keep selecting their pods by the app label. If true, such a workload is
+	// deleted leaving its pods running and created again selecting
+	// app.kubernetes.io/name and app.kubernetes.io/instance, adopting the
+	// existing pods.
+	MigrateSelector bool `json:"migrateSelector,omitempty"`
+
+	// Hooks run by the application container after it starts and before it is
+	// stopped.
+	Lifecycle *v1alpha1.SimpleAppLifecycle `json:"lifecycle,omitempty"`
+	// Seconds the pods are given to stop before they are killed. Defaults to
+	// 30, or to drainSeconds plus 30 when drainSeconds is set.
+	// +kubebuilder:validation:Minimum=0
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+	// Seconds the application container waits before it is stopped, so that
+	// it stops receiving traffic before it shuts down.
+	// +kubebuilder:validation:Minimum=1
+	DrainSeconds *int32 `json:"drainSeconds,omitempty"`
+}
+
+// +kubebuilder:validation:XValidation:rule="!has(self.replicas) || self.replicas == 1 || !has(self.type) || self.type in ['Deployment', 'StatefulSet']",message="replicas is only supported with workload type Deployment or StatefulSet"
+type SimpleAppWorkload struct {
+	// Kind of workload to create. A StatefulSet also gets a headless Service
+	// named <name>-headless. Job and CronJob run the container to completion;
+	// a changed Job is deleted and run again. A DaemonSet runs one pod on each
+	// eligible node. Defaults to Deployment.
+	// +kubebuilder:validation:Enum=Deployment;StatefulSet;Job;CronJob;DaemonSet
+	// +kubebuilder:default=Deployment
+	Type string `json:"type,omitempty"`
+	// Number of desired pods. Only Deployments and StatefulSets can have
+	// other than 1, so the scale subresource only applies to them.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=1
+	Replicas *int32 `json:"replicas,omitempty"`
+	// The strategy to use to replace existing pods with new ones. Only used
+	// with workload type Deployment and DaemonSet.
+	Strategy *v1alpha1.SimpleAppStrategy `json:"strategy,omitempty"`
+	// Minimum number of seconds for which a newly created pod should be ready
+	// without any of its container crashing, for it to be considered
+	// available. Defaults to 0.
+	// +kubebuilder:validation:Minimum=0
+	MinReadySeconds int32 `json:"minReadySeconds,omitempty"`
+	// The maximum time in seconds for a deployment to make progress before it
+	// is considered to be failed. Defaults to 600. Only used with workload
+	// type Deployment.
+	// +kubebuilder:validation:Minimum=1
+	ProgressDeadlineSeconds *int32 `json:"progressDeadlineSeconds,omitempty"`
+	// The number of old ReplicaSets to retain to allow rollback. Defaults to
+	// 10.
+	// +kubebuilder:validation:Minimum=0
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+	// Options for workload type Job and CronJob.
+	Job *v1alpha1.SimpleAppJob `json:"job,omitempty"`
+}
+
+type SimpleAppService struct {
+	// Type of the main Service. Must be ClusterIP, NodePort, or LoadBalancer.
+	// Defaults to ClusterIP.
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	// +kubebuilder:default=ClusterIP
+	Type corev1.ServiceType `json:"type,omitempty"`
+
+	v1alpha1.SimpleAppService `json:",inline"`
+}
+
+type SimpleAppPort struct {
+	// If specified, this must be an IANA_SVC_NAME and unique within the pod.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name,omitempty"`
+	// Port exposed by the Services of the SimpleApp. Must be a valid port
+	// number, 0 < x < 65536.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	ServicePort int32 `json:"servicePort"`
+	// Number of port to expose on the pod's IP address. This must be a valid
+	// port number, 0 < x < 65536.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	ContainerPort int32 `json:"containerPort"`
+	// Protocol for port. Must be UDP, TCP, or SCTP. Defaults to "TCP".
+	// +kubebuilder:validation:Enum=SCTP;TCP;UDP
+	// +kubebuilder:default=TCP
+	Protocol corev1.Protocol `json:"protocol,omitempty"`
+	// Port on each node on which this port is exposed by Services of type
+	// NodePort or LoadBalancer. Allocated by the system if not specified.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	NodePort int32 `json:"nodePort,omitempty"`
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/pecio/simpleapp/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Builds init containers and sidecars. Sidecars with restartPolicy Always are
+// rendered as native sidecars, which are init containers started before the
+// regular ones so that these can use them.
+func (sa *SimpleApp) buildExtraContainers(volumeMounts []corev1.VolumeMount, securityContext *corev1.SecurityContext) ([]corev1.Container, []corev1.Container, error) {
+	names := map[string]struct{}{sa.Name: {}}
+	initContainers := make([]corev1.Container, 0)
+	nativeSidecars := make([]corev1.Container, 0)
+	sidecars := make([]corev1.Container, 0)
+
+	for _, saContainer := range sa.Spec.InitContainers {
+		if saContainer.RestartPolicy != nil {
+			return nil, nil, fmt.Errorf("init container %v in %v.%v cannot have restartPolicy, use a sidecar", saContainer.Name, sa.Namespace, sa.Name)
+		}
+		container, err := sa.makeContainer(saContainer, names, volumeMounts, securityContext)
+		if err != nil {
+			return nil, nil, err
+		}
+		initContainers = append(initContainers, container)
+	}
+	for _, saContainer := range sa.Spec.Sidecars {
+		container, err := sa.makeContainer(saContainer, names, volumeMounts, securityContext)
+		if err != nil {
+			return nil, nil, err
+		}
+		if saContainer.RestartPolicy == nil {
+			sidecars = append(sidecars, container)
+		} else if *saContainer.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			container.RestartPolicy = saContainer.RestartPolicy
+			nativeSidecars = append(nativeSidecars, container)
+		} else {
+			return nil, nil, fmt.Errorf("sidecar %v in %v.%v has unsupported restartPolicy %v", saContainer.Name, sa.Namespace, sa.Name, *saContainer.RestartPolicy)
+		}
+	}
+
+	if len(nativeSidecars) == 0 && len(initContainers) == 0 {
+		initContainers = nil
+	} else {
+		initContainers = append(nativeSidecars, initContainers...)
+	}
+	if len(sidecars) == 0 {
+		sidecars = nil
+	}
+	return initContainers, sidecars, nil
+}
+
+func (sa *SimpleApp) makeContainer(saContainer v1alpha1.SimpleAppContainer, names map[string]struct{}, volumeMounts []corev1.VolumeMount, securityContext *corev1.SecurityContext) (corev1.Container, error) {
+	if _, ok := names[saContainer.Name]; ok {
+		return corev1.Container{}, fmt.Errorf("duplicate container name %v in %v.%v", saContainer.Name, sa.Namespace, sa.Name)
+	}
+	names[saContainer.Name] = struct{}{}
+
+	containerMounts := make([]corev1.VolumeMount, 0, len(saContainer.Volumes))
+outer:
+	for _, saVolume := range saContainer.Volumes {
+		for _, volumeMount := range volumeMounts {
+			if volumeMount.MountPath == saVolume.MountPath {
+				containerMounts = append(containerMounts, volumeMount)
+				continue outer
+			}
+		}
+		return corev1.Container{}, fmt.Errorf("container %v in %v.%v references undeclared volume %v", saContainer.Name, sa.Namespace, sa.Name, saVolume.MountPath)
+	}
+
+	container := corev1.Container{
+		Name:            saContainer.Name,
+		Image:           saContainer.Image,
+		Command:         saContainer.Command,
+		Args:            saContainer.Args,
+		Env:             saContainer.Env,
+		Ports:           saContainer.Ports,
+		VolumeMounts:    containerMounts,
+		SecurityContext: securityContext.DeepCopy(),
+	}
+	if saContainer.Resources != nil {
+		container.Resources = *saContainer.Resources
+	}
+	return container, nil
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	crdName         = "simpleapps.apps.raulpedroche.es"
+	versionV1alpha1 = "apps.raulpedroche.es/v1alpha1"
+	versionV1beta1  = "apps.raulpedroche.es/v1beta1"
+)
+
+// Fields of the v1alpha1 spec that v1beta1 groups in its workload block,
+// along with their name there
+var workloadFields = map[string]string{
+	"workloadType":            "type",
+	"replicas":                "replicas",
+	"strategy":                "strategy",
+	"minReadySeconds":         "minReadySeconds",
+	"progressDeadlineSeconds": "progressDeadlineSeconds",
+	"revisionHistoryLimit":    "revisionHistoryLimit",
+	"job":                     "job",
+}
+
+// Converts a SimpleApp between v1alpha1 and v1beta1. v1beta1 moves
+// serviceType into the service block, groups the workload fields in a
+// workload block and renames the hostPort of ports, which is the port of the
+// Services, to servicePort. Everything else is left as is, so that no field
+// is lost.
+func convertSimpleApp(object map[string]interface{}, apiVersion string) error {
+	if object["apiVersion"] == apiVersion {
+		return nil
+	}
+	spec, _ := object["spec"].(map[string]interface{})
+	switch apiVersion {
+	case versionV1beta1:
+		if spec != nil {
+			specToV1beta1(spec)
+		}
+	case versionV1alpha1:
+		if spec != nil {
+			specToV1alpha1(spec)
+		}
+	default:
+		return fmt.Errorf("cannot convert SimpleApp %v to unknown version %v", objectName(object), apiVersion)
+	}
+	object["apiVersion"] = apiVersion
+	return nil
+}
+
+func specToV1beta1(spec map[string]interface{}) {
+	workload := make(map[string]interface{})
+	for alphaField, betaField := range workloadFields {
+		if value, ok := spec[alphaField]; ok {
+			workload[betaField] = value
+			delete(spec, alphaField)
+		}
+	}
+	if len(workload) > 0 {
+		spec["workload"] = workload
+	}
+
+	if serviceType, ok := spec["serviceType"]; ok {
+		service, _ := spec["service"].(map[string]interface{})
+		if service == nil {
+			service = make(map[string]interface{})
+		}
+		service["type"] = serviceType
+		spec["service"] = service
+		delete(spec, "serviceType")
+	}
+
+	renamePortField(spec, "hostPort", "servicePort")
+}
+
+func specToV1alpha1(spec map[string]interface{}) {
+	if workload, ok := spec["workload"].(map[string]interface{}); ok {
+		for alphaField, betaField := range workloadFields {
+			if value, ok := workload[betaField]; ok {
+				spec[alphaField] = value
+			}
+		}
+		delete(spec, "workload")
+	}
+
+	if service, ok := spec["service"].(map[string]interface{}); ok {
+		if serviceType, ok := service["type"]; ok {
+			spec["serviceType"] = serviceType
+			delete(service, "type")
+			if len(service) == 0 {
+				delete(spec, "service")
+			}
+		}
+	}
+
+	renamePortField(spec, "servicePort", "hostPort")
+}
+
+func renamePortField(spec map[string]interface{}, from, to string) {
+	ports, _ := spec["ports"].([]interface{})
+	for _, item := range ports {
+		port, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if value, ok := port[from]; ok {
+			port[to] = value
+			delete(port, from)
+		}
+	}
+}
+
+func objectName(object map[string]interface{}) string {
+	metadata, _ := object["metadata"].(map[string]interface{})
+	return fmt.Sprintf("%v.%v", metadata["namespace"], metadata["name"])
+}
+
+// Serves a ConversionReview, converting all of its objects or none
+func serveConversion(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var review apiextensionsv1.ConversionReview
+	err = decodeJSON(body, &review)
+	if err != nil || review.Request == nil {
+		http.Error(w, "expected a ConversionReview with a request", http.StatusBadRequest)
+		return
+	}
+
+	response := &apiextensionsv1.ConversionResponse{
+		UID:    review.Request.UID,
+		Result: metav1.Status{Status: metav1.StatusSuccess},
+	}
+	for _, rawObject := range review.Request.Objects {
+		var object map[string]interface{}
+		err := decodeJSON(rawObject.Raw, &object)
+		if err == nil {
+			err = convertSimpleApp(object, review.Request.DesiredAPIVersion)
+		}
+		var converted []byte
+		if err == nil {
+			converted, err = json.Marshal(object)
+		}
+		if err != nil {
+			response.ConvertedObjects = nil
+			response.Result = metav1.Status{Status: metav1.StatusFailure, Message: err.Error()}
+			break
+		}
+		response.ConvertedObjects = append(response.ConvertedObjects, runtime.RawExtension{Raw: converted})
+	}
+
+	review.Request = nil
+	review.Response = response
+	payload, err := json.Marshal(review)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(payload)
+}
+
+// Decodes numbers as json.Number, so that large integers survive the round
+// trip instead of turning into float64
+func decodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// Whether the CRD converts between versions through our webhook
+func conversionWebhookEnabled(clientset *kubernetes.Clientset) (bool, error) {
+	crd, err := getCRD(clientset)
+	if err != nil {
+		return false, err
+	}
+	return crd.Spec.Conversion != nil && crd.Spec.Conversion.Strategy == apiextensionsv1.WebhookConverter, nil
+}
+
+func getCRD(clientset *kubernetes.Clientset) (*apiextensionsv1.CustomResourceDefinition, error) {
+	result := clientset.RESTClient().Get().AbsPath("/apis/apiextensions.k8s.io/v1/customresourcedefinitions/" + crdName).Do(context.TODO())
+	if result.Error() != nil {
+		return nil, result.Error()
+	}
+	content, err := result.Raw()
+	if err != nil {
+		return nil, err
+	}
+	var crd apiextensionsv1.CustomResourceDefinition
+	err = json.Unmarshal(content, &crd)
+	if err != nil {
+		return nil, err
+	}
+	return &crd, nil
+}
+
+// Makes the API server trust our self-signed certificate for conversions
+func injectConversionCABundle(clientset *kubernetes.Clientset, caBundle []byte) error {
+	crd, err := getCRD(clientset)
+	if err != nil {
+		return err
+	}
+	if crd.Spec.Conversion == nil || crd.Spec.Conversion.Webhook == nil || crd.Spec.Conversion.Webhook.ClientConfig == nil {
+		return nil
+	}
+	if bytes.Equal(crd.Spec.Conversion.Webhook.ClientConfig.CABundle, caBundle) {
+		return nil
+	}
+	crd.Spec.Conversion.Webhook.ClientConfig.CABundle = caBundle
+	payload, err := json.Marshal(crd)
+	if err != nil {
+		return err
+	}
+	result := clientset.RESTClient().Put().AbsPath("/apis/apiextensions.k8s.io/v1/customresourcedefinitions/" + crdName).Body(payload).Do(context.TODO())
+	if result.Error() != nil {
+		return result.Error()
+	}
+	log.Printf("Updated conversion caBundle of CustomResourceDefinition %v", crdName)
+	return nil
+}
+
+// Objects keep the version they were written with until they are written
+// again, so old versions cannot be dropped from the CRD until every SimpleApp
+// has been rewritten. This logs how to do it when needed.
+func checkStoredVersions(clientset *kubernetes.Clientset) error {
+	crd, err := getCRD(clientset)
+	if err != nil {
+		return err
+	}
+	storageVersion := ""
+	for _, version := range crd.Spec.Versions {
+		if version.Storage {
+			storageVersion = version.Name
+		}
+	}
+	for _, storedVersion := range crd.Status.StoredVersions {
+		if storedVersion == storageVersion {
+			continue
+		}
+		log.Printf("SimpleApps may still be stored as %v. To migrate them to %v, rewrite them all with "+
+			"'kubectl get simpleapps --all-namespaces -o json | kubectl replace -f -' and then remove %v from the storedVersions of the CRD with "+
+			"'kubectl patch crd %v --subresource=status --type=json -p '[{\"op\":\"replace\",\"path\":\"/status/storedVersions\",\"value\":[\"%v\"]}]''",
+			storedVersion, storageVersion, storedVersion, crdName, storageVersion)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func mustDecode(t *testing.T, data string) map[string]interface{} {
+	t.Helper()
+	var object map[string]interface{}
+	err := decodeJSON([]byte(data), &object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return object
+}
+
+func TestSpecConversionRoundTrip(t *testing.T) {
+	tests := []struct {
+		name        string
+		alpha, beta string
+	}{
+		{
+			name:  "empty",
+			alpha: `{}`,
+			beta:  `{}`,
+		},
+		{
+			name: "workload",
+			alpha: `{"image": "nginx:1.27", "workloadType": "StatefulSet", "replicas": 3,
+				"strategy": {"type": "RollingUpdate"}, "minReadySeconds": 10,
+				"progressDeadlineSeconds": 600, "revisionHistoryLimit": 5}`,
+			beta: `{"image": "nginx:1.27", "workload": {"type": "StatefulSet", "replicas": 3,
+				"strategy": {"type": "RollingUpdate"}, "minReadySeconds": 10,
+				"progressDeadlineSeconds": 600, "revisionHistoryLimit": 5}}`,
+		},
+		{
+			name:  "job",
+			alpha: `{"workloadType": "CronJob", "job": {"schedule": "0 * * * *"}}`,
+			beta:  `{"workload": {"type": "CronJob", "job": {"schedule": "0 * * * *"}}}`,
+		},
+		{
+			name:  "service type",
+			alpha: `{"serviceType": "NodePort"}`,
+			beta:  `{"service": {"type": "NodePort"}}`,
+		},
+		{
+			name:  "service type and options",
+			alpha: `{"serviceType": "LoadBalancer", "service": {"annotations": {"a": "b"}}}`,
+			beta:  `{"service": {"type": "LoadBalancer", "annotations": {"a": "b"}}}`,
+		},
+		{
+			name:  "ports",
+			alpha: `{"ports": [{"containerPort": 8080, "hostPort": 80}, {"containerPort": 9090}]}`,
+			beta:  `{"ports": [{"containerPort": 8080, "servicePort": 80}, {"containerPort": 9090}]}`,
+		},
+		{
+			name:  "large integers",
+			alpha: `{"replicas": 9007199254740993}`,
+			beta:  `{"workload": {"replicas": 9007199254740993}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := mustDecode(t, tt.alpha)
+			specToV1beta1(spec)
+			if want := mustDecode(t, tt.beta); !reflect.DeepEqual(spec, want) {
+				t.Errorf("specToV1beta1() = %v, want %v", spec, want)
+			}
+			specToV1alpha1(spec)
+			if want := mustDecode(t, tt.alpha); !reflect.DeepEqual(spec, want) {
+				t.Errorf("v1alpha1 round trip = %v, want %v", spec, want)
+			}
+
+			spec = mustDecode(t, tt.beta)
+			specToV1alpha1(spec)
+			if want := mustDecode(t, tt.alpha); !reflect.DeepEqual(spec, want) {
+				t.Errorf("specToV1alpha1() = %v, want %v", spec, want)
+			}
+			specToV1beta1(spec)
+			if want := mustDecode(t, tt.beta); !reflect.DeepEqual(spec, want) {
+				t.Errorf("v1beta1 round trip = %v, want %v", spec, want)
+			}
+		})
+	}
+}
+
+func TestServeConversion(t *testing.T) {
+	object := `{"apiVersion": "apps.raulpedroche.es/v1alpha1", "kind": "SimpleApp",
+		"metadata": {"name": "test", "namespace": "default"},
+		"spec": {"image": "nginx:1.27", "replicas": 9007199254740993, "serviceType": "NodePort"}}`
+	review := apiextensionsv1.ConversionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apiextensions.k8s.io/v1", Kind: "ConversionReview"},
+		Request: &apiextensionsv1.ConversionRequest{
+			UID:               "1234",
+			DesiredAPIVersion: versionV1beta1,
+			Objects:           []runtime.RawExtension{{Raw: []byte(object)}},
+		},
+	}
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	serveConversion(recorder, httptest.NewRequest(http.MethodPost, "/convert", bytes.NewReader(body)))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("serveConversion() returned status %v: %v", recorder.Code, recorder.Body.String())
+	}
+
+	var response apiextensionsv1.ConversionReview
+	err = json.Unmarshal(recorder.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Response == nil || response.Response.UID != "1234" || response.Response.Result.Status != metav1.StatusSuccess {
+		t.Fatalf("unexpected response %+v", response.Response)
+	}
+	if len(response.Response.ConvertedObjects) != 1 {
+		t.Fatalf("got %v converted objects, want 1", len(response.Response.ConvertedObjects))
+	}
+	converted := mustDecode(t, string(response.Response.ConvertedObjects[0].Raw))
+	want := mustDecode(t, strings.Replace(object, `"replicas": 9007199254740993, "serviceType": "NodePort"`,
+		`"workload": {"replicas": 9007199254740993}, "service": {"type": "NodePort"}`, 1))
+	want["apiVersion"] = versionV1beta1
+	if !reflect.DeepEqual(converted, want) {
+		t.Errorf("converted object = %v, want %v", converted, want)
+	}
+}
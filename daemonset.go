@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/pecio/simpleapp/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DaemonSets run one pod per eligible node, so replicas is ignored
+func (sa *SimpleApp) buildDaemonSet() (appsv1.DaemonSet, error) {
+	podTemplate, err := sa.buildPodTemplate()
+	if err != nil {
+		return appsv1.DaemonSet{}, err
+	}
+	updateStrategy, err := sa.buildDaemonSetStrategy()
+	if err != nil {
+		return appsv1.DaemonSet{}, err
+	}
+	daemonSet := appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   sa.Name,
+			Labels: sa.labels(),
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Template:             podTemplate,
+			Selector:             sa.selector(),
+			UpdateStrategy:       updateStrategy,
+			MinReadySeconds:      sa.Spec.MinReadySeconds,
+			RevisionHistoryLimit: sa.Spec.RevisionHistoryLimit,
+		},
+	}
+	return daemonSet, nil
+}
+
+func reconcileDaemonSet(clientset *kubernetes.Clientset, namespace string, newDaemonSet appsv1.DaemonSet) error {
+	// Check if DaemonSet exists
+	oldDaemonSet, err := clientset.AppsV1().DaemonSets(namespace).Get(context.TODO(), newDaemonSet.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = clientset.AppsV1().DaemonSets(namespace).Create(context.TODO(), &newDaemonSet, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		log.Printf("Created DaemonSet %v.%v", namespace, newDaemonSet.Name)
+	} else if err != nil {
+		return err
+	} else {
+		// Check if DaemonSet is ours
+		managedBy, ok := oldDaemonSet.ObjectMeta.Labels[managedByLabel]
+		if !ok || managedBy != managedByValue {
+			return fmt.Errorf("found DaemonSet %v.%v not managed by us", oldDaemonSet.ObjectMeta.Namespace, oldDaemonSet.ObjectMeta.Name)
+		}
+
+		if !utils.DaemonSetEqual(newDaemonSet, *oldDaemonSet) {
+			_, err = clientset.AppsV1().DaemonSets(oldDaemonSet.ObjectMeta.Namespace).Update(context.TODO(), &newDaemonSet, metav1.UpdateOptions{})
+			if err != nil {
+				return err
+			}
+			log.Printf("DaemonSet %v.%v updated", oldDaemonSet.ObjectMeta.Namespace, oldDaemonSet.ObjectMeta.Name)
+		}
+	}
+	return nil
+}
+
+func deleteDaemonSet(clientset *kubernetes.Clientset, namespace, name string) error {
+	// Get current DaemonSet
+	oldDaemonSet, err := clientset.AppsV1().DaemonSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		log.Printf("DaemonSet %v.%v already deleted", namespace, name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	managedBy, ok := oldDaemonSet.Labels[managedByLabel]
+	if !ok || managedBy != managedByValue {
+		return fmt.Errorf("found DaemonSet %v.%v not managed by us", oldDaemonSet.ObjectMeta.Namespace, oldDaemonSet.ObjectMeta.Name)
+	}
+	err = clientset.AppsV1().DaemonSets(oldDaemonSet.ObjectMeta.Namespace).Delete(context.TODO(), oldDaemonSet.ObjectMeta.Name, metav1.DeleteOptions{})
+	if err != nil {
+		return err
+	}
+	log.Printf("Deleted DaemonSet %v.%v", namespace, name)
+	return nil
+}
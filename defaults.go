@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A JSON Patch operation, as returned by mutating webhooks
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Fills in the defaults of a SimpleApp at admission that the CRD cannot
+// express, as patches so that fields unknown to the controller are left
+// alone. Unnamed ports are named after their protocol and port unless that
+// collides with another port.
+func (sa *SimpleApp) defaultingPatch() []jsonPatchOperation {
+	patch := make([]jsonPatchOperation, 0)
+
+	names := make(map[string]struct{}, len(sa.Spec.Ports))
+	for _, port := range sa.Spec.Ports {
+		if port.Name != "" {
+			names[fmt.Sprintf("%.13v", port.Name)] = struct{}{}
+		}
+	}
+	for i, port := range sa.Spec.Ports {
+		if port.Name != "" {
+			continue
+		}
+		name := strings.ToLower(fmt.Sprintf("%s-%d", defaultProtocol(port.Protocol), port.HostPort))
+		if _, ok := names[name]; ok {
+			continue
+		}
+		names[name] = struct{}{}
+		patch = append(patch, jsonPatchOperation{Op: "add", Path: fmt.Sprintf("/spec/ports/%d/name", i), Value: name})
+	}
+	return patch
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestMutateAdmission(t *testing.T) {
+	tests := []struct {
+		name  string
+		ports string
+		// Empty when nothing needs defaulting
+		wantPatch string
+	}{
+		{
+			name:  "no ports",
+			ports: `[]`,
+		},
+		{
+			name:  "named port",
+			ports: `[{"name": "http", "hostPort": 80, "containerPort": 8080, "protocol": "TCP"}]`,
+		},
+		{
+			name:  "unnamed ports",
+			ports: `[{"hostPort": 80, "containerPort": 8080, "protocol": "TCP"}, {"hostPort": 53, "containerPort": 53, "protocol": "UDP"}]`,
+			wantPatch: `[{"op":"add","path":"/spec/ports/0/name","value":"tcp-80"},` +
+				`{"op":"add","path":"/spec/ports/1/name","value":"udp-53"}]`,
+		},
+		{
+			name:      "unnamed port without protocol",
+			ports:     `[{"hostPort": 8443, "containerPort": 8443}]`,
+			wantPatch: `[{"op":"add","path":"/spec/ports/0/name","value":"tcp-8443"}]`,
+		},
+		{
+			name: "name taken by another port",
+			ports: `[{"hostPort": 80, "containerPort": 8080, "protocol": "TCP"},
+				{"name": "tcp-80", "hostPort": 8080, "containerPort": 8081, "protocol": "TCP"},
+				{"hostPort": 443, "containerPort": 8443, "protocol": "TCP"}]`,
+			wantPatch: `[{"op":"add","path":"/spec/ports/2/name","value":"tcp-443"}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			object := `{"apiVersion": "apps.raulpedroche.es/v1alpha1", "kind": "SimpleApp",
+				"metadata": {"name": "test", "namespace": "default"},
+				"spec": {"image": "nginx:1.27", "replicas": 1, "serviceType": "ClusterIP", "ports": ` + tt.ports + `}}`
+			response := mutateAdmission(&admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				Object:    runtime.RawExtension{Raw: []byte(object)},
+			})
+			if !response.Allowed {
+				t.Fatalf("denied with %v", response.Result.Message)
+			}
+			if string(response.Patch) != tt.wantPatch {
+				t.Errorf("patch = %s, want %s", response.Patch, tt.wantPatch)
+			}
+			if tt.wantPatch != "" && (response.PatchType == nil || *response.PatchType != admissionv1.PatchTypeJSONPatch) {
+				t.Errorf("patchType = %v, want %v", response.PatchType, admissionv1.PatchTypeJSONPatch)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// Decodes a SimpleApp named test in the default namespace, with the spec
+// given as JSON
+func testSimpleApp(t *testing.T, spec string) SimpleApp {
+	t.Helper()
+	var sa SimpleApp
+	err := json.Unmarshal([]byte(`{"metadata": {"name": "test", "namespace": "default"}, "spec": `+spec+`}`), &sa)
+	if err != nil {
+		t.Fatalf("cannot decode spec %v: %v", spec, err)
+	}
+	return sa
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"log"
+
+	"github.com/pecio/simpleapp/api/v1alpha1"
+	"github.com/pecio/simpleapp/pkg/generated/clientset/versioned"
+	"github.com/pecio/simpleapp/utils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Stored on the Jobs we create, and in the status of their SimpleApp once they
+// run
+const jobSpecHashAnnotation = "apps.raulpedroche.es/spec-hash"
+
+func (sa *SimpleApp) buildJobSpec() (batchv1.JobSpec, error) {
+	podTemplate, err := sa.buildPodTemplate()
+	if err != nil {
+		return batchv1.JobSpec{}, err
+	}
+	saJob := sa.Spec.Job
+	if saJob == nil {
+		saJob = &v1alpha1.SimpleAppJob{}
+	}
+	switch saJob.RestartPolicy {
+	case "":
+		podTemplate.Spec.RestartPolicy = corev1.RestartPolicyOnFailure
+	case corev1.RestartPolicyOnFailure, corev1.RestartPolicyNever:
+		podTemplate.Spec.RestartPolicy = saJob.RestartPolicy
+	default:
+		return batchv1.JobSpec{}, fmt.Errorf("restartPolicy %v not allowed for Jobs in %v.%v", saJob.RestartPolicy, sa.Namespace, sa.Name)
+	}
+	jobSpec := batchv1.JobSpec{
+		Template:                podTemplate,
+		BackoffLimit:            saJob.BackoffLimit,
+		ActiveDeadlineSeconds:   saJob.ActiveDeadlineSeconds,
+		TTLSecondsAfterFinished: saJob.TTLSecondsAfterFinished,
+	}
+	return jobSpec, nil
+}
+
+func (sa *SimpleApp) buildJob() (batchv1.Job, error) {
+	if sa.Spec.Job != nil && sa.Spec.Job.Schedule != "" {
+		return batchv1.Job{}, fmt.Errorf("schedule is only allowed with workloadType CronJob in %v.%v", sa.Namespace, sa.Name)
+	}
+	jobSpec, err := sa.buildJobSpec()
+	if err != nil {
+		return batchv1.Job{}, err
+	}
+	job := batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   sa.Name,
+			Labels: sa.labels(),
+		},
+		Spec: jobSpec,
+	}
+	hash, err := jobSpecHash(job)
+	if err != nil {
+		return batchv1.Job{}, err
+	}
+	job.Annotations = map[string]string{jobSpecHashAnnotation: hash}
+	if sa.Spec.Suspend {
+		job.Spec.Suspend = &sa.Spec.Suspend
+	}
+	return job, nil
+}
+
+// Hashes the labels and spec of a Job, leaving out suspend, which is changed
+// in place
+func jobSpecHash(job batchv1.Job) (string, error) {
+	content, err := json.Marshal(struct {
+		Labels map[string]string
+		Spec   batchv1.JobSpec
+	}{job.Labels, job.Spec})
+	if err != nil {
+		return "", err
+	}
+	return rand.SafeEncodeString(fmt.Sprintf("%x", crc32.ChecksumIEEE(content))), nil
+}
+
+func (sa *SimpleApp) buildCronJob() (batchv1.CronJob, error) {
+	if sa.Spec.Job == nil || sa.Spec.Job.Schedule == "" {
+		return batchv1.CronJob{}, fmt.Errorf("workloadType CronJob requires a schedule in %v.%v", sa.Namespace, sa.Name)
+	}
+	jobSpec, err := sa.buildJobSpec()
+	if err != nil {
+		return batchv1.CronJob{}, err
+	}
+	cronJob := batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   sa.Name,
+			Labels: sa.labels(),
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   sa.Spec.Job.Schedule,
+			Suspend:                    &sa.Spec.Suspend,
+			TimeZone:                   sa.Spec.Job.TimeZone,
+			ConcurrencyPolicy:          sa.Spec.Job.ConcurrencyPolicy,
+			StartingDeadlineSeconds:    sa.Spec.Job.StartingDeadlineSeconds,
+			SuccessfulJobsHistoryLimit: sa.Spec.Job.SuccessfulJobsHistoryLimit,
+			FailedJobsHistoryLimit:     sa.Spec.Job.FailedJobsHistoryLimit,
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: sa.labels(),
+				},
+				Spec: jobSpec,
+			},
+		},
+	}
+	return cronJob, nil
+}
+
+// Jobs cannot be updated, so a changed Job is deleted and created again. This
+// runs it again with the new configuration. A Job gone after it ran, deleted
+// by its TTL or by hand, is not created again until its spec changes.
+func (sa *SimpleApp) reconcileJob(clientset kubernetes.Interface, appClientset versioned.Interface, newJob batchv1.Job) error {
+	namespace := sa.Namespace
+	// Check if Job exists
+	oldJob, err := clientset.BatchV1().Jobs(namespace).Get(context.TODO(), newJob.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		lastRun := sa.Status.LastRun
+		if lastRun != nil && lastRun.SpecHash == newJob.Annotations[jobSpecHashAnnotation] {
+			return nil
+		}
+		// The run is recorded first, so that a failure later in this pass
+		// cannot make it run twice
+		err = sa.recordRun(appClientset, &v1alpha1.SimpleAppRunStatus{
+			JobName:  newJob.Name,
+			Result:   runResultRunning,
+			SpecHash: newJob.Annotations[jobSpecHashAnnotation],
+		})
+		if err != nil {
+			return err
+		}
+		_, err = clientset.BatchV1().Jobs(namespace).Create(context.TODO(), &newJob, metav1.CreateOptions{})
+		if err != nil {
+			// Let the next pass try again
+			if recordErr := sa.recordRun(appClientset, lastRun); recordErr != nil {
+				log.Printf("Failed to restore the last run of SimpleApp %v.%v: %v", namespace, sa.Name, recordErr)
+			}
+			return err
+		}
+		log.Printf("Created Job %v.%v", namespace, newJob.Name)
+	} else if err != nil {
+		return err
+	} else {
+		// Check if Job is ours
+		managedBy, ok := oldJob.ObjectMeta.Labels[managedByLabel]
+		if !ok || managedBy != managedByValue {
+			return fmt.Errorf("found Job %v.%v not managed by us", oldJob.ObjectMeta.Namespace, oldJob.ObjectMeta.Name)
+		}
+
+		if !utils.JobEqual(newJob, *oldJob) {
+			err = deleteJob(clientset, namespace, oldJob.ObjectMeta.Name)
+			if err != nil {
+				return err
+			}
+			// The new Job is created once the old one is gone
+			log.Printf("Job %v.%v changed, it will be run again", oldJob.ObjectMeta.Namespace, oldJob.ObjectMeta.Name)
+		} else if !utils.JobSuspendEqual(newJob, *oldJob) {
+			// Suspending and resuming is the one change made in place
+			oldJob.Spec.Suspend = newJob.Spec.Suspend
+			_, err = clientset.BatchV1().Jobs(oldJob.ObjectMeta.Namespace).Update(context.TODO(), oldJob, metav1.UpdateOptions{})
+			if err != nil {
+				return err
+			}
+			log.Printf("Job %v.%v suspended or resumed", oldJob.ObjectMeta.Namespace, oldJob.ObjectMeta.Name)
+		}
+	}
+	return nil
+}
+
+// Writes the last run to the status subresource
+func (sa *SimpleApp) recordRun(appClientset versioned.Interface, lastRun *v1alpha1.SimpleAppRunStatus) error {
+	sa.Status.LastRun = lastRun
+	updated, err := appClientset.AppsV1alpha1().SimpleApps(sa.Namespace).UpdateStatus(context.TODO(), &sa.SimpleApp, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	sa.SimpleApp = *updated
+	return nil
+}
+
+func reconcileCronJob(clientset *kubernetes.Clientset, namespace string, newCronJob batchv1.CronJob) error {
+	// Check if CronJob exists
+	oldCronJob, err := clientset.BatchV1().CronJobs(namespace).Get(context.TODO(), newCronJob.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = clientset.BatchV1().CronJobs(namespace).Create(context.TODO(), &newCronJob, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		log.Printf("Created CronJob %v.%v", namespace, newCronJob.Name)
+	} else if err != nil {
+		return err
+	} else {
+		// Check if CronJob is ours
+		managedBy, ok := oldCronJob.ObjectMeta.Labels[managedByLabel]
+		if !ok || managedBy != managedByValue {
+			return fmt.Errorf("found CronJob %v.%v not managed by us", oldCronJob.ObjectMeta.Namespace, oldCronJob.ObjectMeta.Name)
+		}
+
+		if !utils.CronJobEqual(newCronJob, *oldCronJob) {
+			_, err = clientset.BatchV1().CronJobs(oldCronJob.ObjectMeta.Namespace).Update(context.TODO(), &newCronJob, metav1.UpdateOptions{})
+			if err != nil {
+				return err
+			}
+			log.Printf("CronJob %v.%v updated", oldCronJob.ObjectMeta.Namespace, oldCronJob.ObjectMeta.Name)
+		}
+	}
+	return nil
+}
+
+func deleteJob(clientset kubernetes.Interface, namespace, name string) error {
+	// Get current Job
+	oldJob, err := clientset.BatchV1().Jobs(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		log.Printf("Job %v.%v already deleted", namespace, name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	managedBy, ok := oldJob.Labels[managedByLabel]
+	if !ok || managedBy != managedByValue {
+		return fmt.Errorf("found Job %v.%v not managed by us", oldJob.ObjectMeta.Namespace, oldJob.ObjectMeta.Name)
+	}
+	// Jobs orphan their pods by default
+	propagationPolicy := metav1.DeletePropagationBackground
+	err = clientset.BatchV1().Jobs(oldJob.ObjectMeta.Namespace).Delete(context.TODO(), oldJob.ObjectMeta.Name, metav1.DeleteOptions{PropagationPolicy: &propagationPolicy})
+	if err != nil {
+		return err
+	}
+	log.Printf("Deleted Job %v.%v", namespace, name)
+	return nil
+}
+
+func deleteCronJob(clientset *kubernetes.Clientset, namespace, name string) error {
+	// Get current CronJob
+	oldCronJob, err := clientset.BatchV1().CronJobs(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		log.Printf("CronJob %v.%v already deleted", namespace, name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	managedBy, ok := oldCronJob.Labels[managedByLabel]
+	if !ok || managedBy != managedByValue {
+		return fmt.Errorf("found CronJob %v.%v not managed by us", oldCronJob.ObjectMeta.Namespace, oldCronJob.ObjectMeta.Name)
+	}
+	propagationPolicy := metav1.DeletePropagationBackground
+	err = clientset.BatchV1().CronJobs(oldCronJob.ObjectMeta.Namespace).Delete(context.TODO(), oldCronJob.ObjectMeta.Name, metav1.DeleteOptions{PropagationPolicy: &propagationPolicy})
+	if err != nil {
+		return err
+	}
+	log.Printf("Deleted CronJob %v.%v", namespace, name)
+	return nil
+}
+
+// Finds the most recent Job run by this SimpleApp, either the Job itself or
+// the last one created by its CronJob
+func (sa *SimpleApp) lastRun(clientset kubernetes.Interface) (*v1alpha1.SimpleAppRunStatus, error) {
+	var lastJob *batchv1.Job
+	switch sa.workloadType() {
+	case workloadJob:
+		job, err := clientset.BatchV1().Jobs(sa.Namespace).Get(context.TODO(), sa.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			// Keep the outcome of a Job deleted after it ran
+			lastRun := sa.Status.LastRun
+			if lastRun != nil && lastRun.Result == runResultRunning {
+				// Deleted before it finished, its outcome is lost
+				lastRun = lastRun.DeepCopy()
+				lastRun.Result = runResultUnknown
+				lastRun.Message = "the Job was deleted before it finished"
+			}
+			return lastRun, nil
+		} else if err != nil {
+			return nil, err
+		}
+		lastJob = job
+	case workloadCronJob:
+		labelSelector := labels.Set(sa.selectorLabels()).String()
+		jobs, err := clientset.BatchV1().Jobs(sa.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
+		if err != nil {
+			return nil, err
+		}
+		for i, job := range jobs.Items {
+			owner := metav1.GetControllerOf(&job)
+			if owner == nil || owner.Kind != "CronJob" || owner.Name != sa.Name {
+				continue
+			}
+			if lastJob == nil || lastJob.CreationTimestamp.Before(&job.CreationTimestamp) {
+				lastJob = &jobs.Items[i]
+			}
+		}
+	default:
+		return nil, nil
+	}
+	if lastJob == nil {
+		return nil, nil
+	}
+
+	runStatus := v1alpha1.SimpleAppRunStatus{
+		JobName:        lastJob.Name,
+		StartTime:      lastJob.Status.StartTime,
+		CompletionTime: lastJob.Status.CompletionTime,
+		Result:         runResultRunning,
+		SpecHash:       lastJob.Annotations[jobSpecHashAnnotation],
+	}
+	for _, condition := range lastJob.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			runStatus.Result = runResultSucceeded
+		case batchv1.JobFailed:
+			runStatus.Result = runResultFailed
+			runStatus.Message = condition.Message
+		}
+	}
+	return &runStatus, nil
+}
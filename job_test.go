@@ -0,0 +1,101 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/pecio/simpleapp/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestJobSpecHash(t *testing.T) {
+	hash := func(spec string) string {
+		t.Helper()
+		sa := testSimpleApp(t, spec)
+		job, err := sa.buildJob()
+		if err != nil {
+			t.Fatal(err)
+		}
+		value, ok := job.Annotations[jobSpecHashAnnotation]
+		if !ok {
+			t.Fatalf("Job has no %v annotation", jobSpecHashAnnotation)
+		}
+		return value
+	}
+
+	original := hash(`{"image": "busybox:1.37", "workloadType": "Job"}`)
+	if got := hash(`{"image": "busybox:1.37", "workloadType": "Job"}`); got != original {
+		t.Errorf("hash of the same spec changed from %v to %v", original, got)
+	}
+	// Suspending updates the Job in place, so it must not look like a new run
+	if got := hash(`{"image": "busybox:1.37", "workloadType": "Job", "suspend": true}`); got != original {
+		t.Errorf("suspending changed the hash from %v to %v", original, got)
+	}
+	if got := hash(`{"image": "busybox:1.38", "workloadType": "Job"}`); got == original {
+		t.Error("a new image kept the hash")
+	}
+	if got := hash(`{"image": "busybox:1.37", "workloadType": "Job", "job": {"ttlSecondsAfterFinished": 60}}`); got == original {
+		t.Error("a new ttlSecondsAfterFinished kept the hash")
+	}
+}
+
+func TestLastRunDeletedJob(t *testing.T) {
+	tests := []struct {
+		name          string
+		stored        *v1alpha1.SimpleAppRunStatus
+		wantResult    string
+		wantCondition metav1.ConditionStatus
+	}{
+		{"never run", nil, "", metav1.ConditionTrue},
+		{"succeeded", &v1alpha1.SimpleAppRunStatus{JobName: "test", Result: runResultSucceeded}, runResultSucceeded, metav1.ConditionTrue},
+		{"failed", &v1alpha1.SimpleAppRunStatus{JobName: "test", Result: runResultFailed}, runResultFailed, metav1.ConditionFalse},
+		{"running", &v1alpha1.SimpleAppRunStatus{JobName: "test", Result: runResultRunning}, runResultUnknown, metav1.ConditionUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sa := testSimpleApp(t, `{"image": "busybox:1.37", "workloadType": "Job"}`)
+			sa.Status.LastRun = tt.stored
+			lastRun, err := sa.lastRun(fake.NewClientset())
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := ""
+			if lastRun != nil {
+				result = lastRun.Result
+			}
+			if result != tt.wantResult {
+				t.Errorf("result = %q, want %q", result, tt.wantResult)
+			}
+			if tt.stored != nil && tt.stored.Result == runResultUnknown {
+				t.Error("the stored last run was modified")
+			}
+			if got := runCondition(lastRun).Status; got != tt.wantCondition {
+				t.Errorf("Ready condition = %v, want %v", got, tt.wantCondition)
+			}
+		})
+	}
+}
+
+func TestReconcileJobAlreadyRun(t *testing.T) {
+	sa := testSimpleApp(t, `{"image": "busybox:1.37", "workloadType": "Job"}`)
+	job, err := sa.buildJob()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sa.Status.LastRun = &v1alpha1.SimpleAppRunStatus{
+		JobName:  job.Name,
+		Result:   runResultUnknown,
+		SpecHash: job.Annotations[jobSpecHashAnnotation],
+	}
+	clientset := fake.NewClientset()
+	// Nothing is recorded, so no SimpleApp clientset is needed
+	err = sa.reconcileJob(clientset, nil, job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "create" {
+			t.Errorf("Job %v created again", job.Name)
+		}
+	}
+}
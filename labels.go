@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	legacyAppLabel = "app"
+	nameLabel      = "app.kubernetes.io/name"
+	instanceLabel  = "app.kubernetes.io/instance"
+	versionLabel   = "app.kubernetes.io/version"
+	componentLabel = "app.kubernetes.io/component"
+	partOfLabel    = "app.kubernetes.io/part-of"
+)
+
+// Labels used in selectors. They cannot be changed on existing workloads, so
+// those created with the bare app label keep using it until migrated.
+func (sa *SimpleApp) selectorLabels() map[string]string {
+	if sa.legacySelector {
+		return map[string]string{
+			legacyAppLabel: sa.Name,
+			managedByLabel: managedByValue,
+		}
+	}
+	return sa.recommendedSelectorLabels()
+}
+
+func (sa *SimpleApp) recommendedSelectorLabels() map[string]string {
+	labels := map[string]string{
+		nameLabel:      sa.Name,
+		instanceLabel:  sa.Name,
+		managedByLabel: managedByValue,
+	}
+	return labels
+}
+
+// Name of the SimpleApp that created an object. Those created before the
+// recommended labels only carry the app label.
+func instanceOf(objectLabels map[string]string) string {
+	if instance, ok := objectLabels[instanceLabel]; ok {
+		return instance
+	}
+	return objectLabels[legacyAppLabel]
+}
+
+// Labels of generated objects: the recommended app.kubernetes.io labels,
+// those propagated from the SimpleApp metadata and the selector labels
+func (sa *SimpleApp) labels() map[string]string {
+	labels := make(map[string]string)
+	for _, key := range sa.Spec.PropagateLabels {
+		if value, ok := sa.Labels[key]; ok {
+			labels[key] = value
+		}
+	}
+	for key, value := range sa.recommendedSelectorLabels() {
+		labels[key] = value
+	}
+	if version := imageVersion(sa.Spec.Image); version != "" {
+		labels[versionLabel] = version
+	}
+	if sa.Spec.Component != "" {
+		labels[componentLabel] = sa.Spec.Component
+	}
+	if sa.Spec.PartOf != "" {
+		labels[partOfLabel] = sa.Spec.PartOf
+	}
+	// Selector labels always win
+	for key, value := range sa.selectorLabels() {
+		labels[key] = value
+	}
+	return labels
+}
+
+func (sa *SimpleApp) podLabels() map[string]string {
+	labels := make(map[string]string)
+	for key, value := range sa.Spec.PodLabels {
+		labels[key] = value
+	}
+	for key, value := range sa.labels() {
+		labels[key] = value
+	}
+	return labels
+}
+
+func (sa *SimpleApp) selector() *metav1.LabelSelector {
+	selector := metav1.LabelSelector{}
+	for key, value := range sa.selectorLabels() {
+		metav1.AddLabelToSelector(&selector, key, value)
+	}
+	return &selector
+}
+
+// Returns the tag of an image reference, if it is a valid label value
+func imageVersion(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	colon := strings.LastIndex(image, ":")
+	if colon <= strings.LastIndex(image, "/") {
+		return ""
+	}
+	version := image[colon+1:]
+	if len(validation.IsValidLabelValue(version)) > 0 {
+		return ""
+	}
+	return version
+}
+
+// Checks whether the existing workload selects pods by the bare app label.
+// With migrateSelector, it is deleted leaving its pods behind once these carry
+// the recommended labels, so that the workload created in its place adopts
+// them without downtime. Returns true while the old workload is being deleted.
+func (sa *SimpleApp) detectLegacySelector(clientset *kubernetes.Clientset) (bool, error) {
+	sa.legacySelector = false
+
+	var objectMeta metav1.ObjectMeta
+	var selector *metav1.LabelSelector
+	var templateLabels map[string]string
+	var deleteWorkload func(context.Context, string, metav1.DeleteOptions) error
+	switch sa.workloadType() {
+	case workloadDeployment:
+		deployment, err := clientset.AppsV1().Deployments(sa.Namespace).Get(context.TODO(), sa.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		objectMeta, selector, templateLabels = deployment.ObjectMeta, deployment.Spec.Selector, deployment.Spec.Template.Labels
+		deleteWorkload = clientset.AppsV1().Deployments(sa.Namespace).Delete
+	case workloadStatefulSet:
+		statefulSet, err := clientset.AppsV1().StatefulSets(sa.Namespace).Get(context.TODO(), sa.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		objectMeta, selector, templateLabels = statefulSet.ObjectMeta, statefulSet.Spec.Selector, statefulSet.Spec.Template.Labels
+		deleteWorkload = clientset.AppsV1().StatefulSets(sa.Namespace).Delete
+	case workloadDaemonSet:
+		daemonSet, err := clientset.AppsV1().DaemonSets(sa.Namespace).Get(context.TODO(), sa.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		objectMeta, selector, templateLabels = daemonSet.ObjectMeta, daemonSet.Spec.Selector, daemonSet.Spec.Template.Labels
+		deleteWorkload = clientset.AppsV1().DaemonSets(sa.Namespace).Delete
+	default:
+		// Jobs and CronJobs do not have selectors of their own
+		return false, nil
+	}
+
+	// Workloads not managed by us are reported when reconciling
+	if objectMeta.Labels[managedByLabel] != managedByValue || selector == nil {
+		return false, nil
+	}
+	if objectMeta.DeletionTimestamp != nil {
+		return true, nil
+	}
+	if _, ok := selector.MatchLabels[legacyAppLabel]; !ok {
+		return false, nil
+	}
+	sa.legacySelector = true
+	if !sa.Spec.MigrateSelector {
+		return false, nil
+	}
+
+	for key, value := range sa.recommendedSelectorLabels() {
+		if templateLabels[key] != value {
+			log.Printf("%v %v.%v will migrate its selector once its pods are relabeled", sa.workloadType(), sa.Namespace, sa.Name)
+			return false, nil
+		}
+	}
+	propagationPolicy := metav1.DeletePropagationOrphan
+	err := deleteWorkload(context.TODO(), sa.Name, metav1.DeleteOptions{PropagationPolicy: &propagationPolicy})
+	if err != nil {
+		return false, err
+	}
+	log.Printf("Deleted %v %v.%v to migrate its selector, keeping its pods", sa.workloadType(), sa.Namespace, sa.Name)
+	return true, nil
+}
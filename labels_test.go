@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestImageVersion(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{"nginx", ""},
+		{"nginx:1.27", "1.27"},
+		{"nginx:latest", "latest"},
+		{"registry.example.com:5000/nginx", ""},
+		{"registry.example.com:5000/team/nginx:1.27-alpine", "1.27-alpine"},
+		{"nginx@sha256:0123456789abcdef", ""},
+		{"nginx:1.27@sha256:0123456789abcdef", "1.27"},
+		{"nginx:" + "v1234567890123456789012345678901234567890123456789012345678901234", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			if got := imageVersion(tt.image); got != tt.want {
+				t.Errorf("imageVersion(%q) = %q, want %q", tt.image, got, tt.want)
+			}
+		})
+	}
+}
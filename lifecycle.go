@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Grace period of pods that do not set one
+const defaultTerminationGracePeriodSeconds = 30
+
+// Sets the lifecycle hooks of the application container and the grace period
+// of the pod. drainSeconds is rendered as a preStop sleep, so that endpoints
+// are removed from Services before the container is sent SIGTERM. The grace
+// period is raised to leave the usual 30 seconds for shutdown after it.
+func (sa *SimpleApp) applyLifecycle(podSpec *corev1.PodSpec) error {
+	podSpec.TerminationGracePeriodSeconds = sa.Spec.TerminationGracePeriodSeconds
+
+	var lifecycle corev1.Lifecycle
+	if sa.Spec.Lifecycle != nil {
+		lifecycle.PostStart = sa.Spec.Lifecycle.PostStart.DeepCopy()
+		lifecycle.PreStop = sa.Spec.Lifecycle.PreStop.DeepCopy()
+	}
+	if sa.Spec.DrainSeconds != nil {
+		if lifecycle.PreStop != nil {
+			return fmt.Errorf("drainSeconds and lifecycle.preStop are mutually exclusive in %v.%v", sa.Namespace, sa.Name)
+		}
+		drainSeconds := int64(*sa.Spec.DrainSeconds)
+		lifecycle.PreStop = &corev1.LifecycleHandler{
+			Sleep: &corev1.SleepAction{Seconds: drainSeconds},
+		}
+		if podSpec.TerminationGracePeriodSeconds == nil {
+			gracePeriod := drainSeconds + defaultTerminationGracePeriodSeconds
+			podSpec.TerminationGracePeriodSeconds = &gracePeriod
+		} else if *podSpec.TerminationGracePeriodSeconds <= drainSeconds {
+			return fmt.Errorf("terminationGracePeriodSeconds must be longer than drainSeconds in %v.%v", sa.Namespace, sa.Name)
+		}
+	}
+
+	if lifecycle.PostStart != nil || lifecycle.PreStop != nil {
+		podSpec.Containers[0].Lifecycle = &lifecycle
+	}
+	return nil
+}
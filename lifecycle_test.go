@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPodTemplateLifecycle(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		// Lifecycle of the application container, as JSON
+		wantLifecycle   string
+		wantGracePeriod int64
+		wantErr         bool
+	}{
+		{
+			name:          "unset",
+			spec:          `{"image": "nginx:1.27"}`,
+			wantLifecycle: `null`,
+		},
+		{
+			name:            "grace period",
+			spec:            `{"image": "nginx:1.27", "terminationGracePeriodSeconds": 120}`,
+			wantLifecycle:   `null`,
+			wantGracePeriod: 120,
+		},
+		{
+			name:            "drain",
+			spec:            `{"image": "nginx:1.27", "drainSeconds": 10}`,
+			wantLifecycle:   `{"preStop":{"sleep":{"seconds":10}}}`,
+			wantGracePeriod: 40,
+		},
+		{
+			name:            "drain within grace period",
+			spec:            `{"image": "nginx:1.27", "drainSeconds": 10, "terminationGracePeriodSeconds": 60}`,
+			wantLifecycle:   `{"preStop":{"sleep":{"seconds":10}}}`,
+			wantGracePeriod: 60,
+		},
+		{
+			name:            "drain and postStart",
+			spec:            `{"image": "nginx:1.27", "drainSeconds": 5, "lifecycle": {"postStart": {"exec": {"command": ["/warmup"]}}}}`,
+			wantLifecycle:   `{"postStart":{"exec":{"command":["/warmup"]}},"preStop":{"sleep":{"seconds":5}}}`,
+			wantGracePeriod: 35,
+		},
+		{
+			name:          "preStop",
+			spec:          `{"image": "nginx:1.27", "lifecycle": {"preStop": {"httpGet": {"path": "/drain", "port": 8080}}}}`,
+			wantLifecycle: `{"preStop":{"httpGet":{"path":"/drain","port":8080}}}`,
+		},
+		{
+			name:    "drain longer than grace period",
+			spec:    `{"image": "nginx:1.27", "drainSeconds": 10, "terminationGracePeriodSeconds": 10}`,
+			wantErr: true,
+		},
+		{
+			name:    "drain and preStop",
+			spec:    `{"image": "nginx:1.27", "drainSeconds": 10, "lifecycle": {"preStop": {"exec": {"command": ["/drain"]}}}}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sa := testSimpleApp(t, tt.spec)
+			template, err := sa.buildPodTemplate()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("buildPodTemplate() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			lifecycle, err := json.Marshal(template.Spec.Containers[0].Lifecycle)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(lifecycle) != tt.wantLifecycle {
+				t.Errorf("lifecycle = %s, want %s", lifecycle, tt.wantLifecycle)
+			}
+			var gracePeriod int64
+			if template.Spec.TerminationGracePeriodSeconds != nil {
+				gracePeriod = *template.Spec.TerminationGracePeriodSeconds
+			}
+			if gracePeriod != tt.wantGracePeriod {
+				t.Errorf("terminationGracePeriodSeconds = %v, want %v", gracePeriod, tt.wantGracePeriod)
+			}
+		})
+	}
+}
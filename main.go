@@ -1,12 +1,20 @@
+//go:generate go tool controller-gen object paths=./api/v1alpha1/...
+//go:generate go run ./cmd/crdgen
+//go:generate go run k8s.io/code-generator/cmd/client-gen@v0.34.0 --clientset-name versioned --input-base github.com/pecio/simpleapp --input api/v1alpha1 --output-dir pkg/generated/clientset --output-pkg github.com/pecio/simpleapp/pkg/generated/clientset --go-header-file hack/boilerplate.go.txt --fake-clientset=false
+//go:generate go run k8s.io/code-generator/cmd/lister-gen@v0.34.0 --output-dir pkg/generated/listers --output-pkg github.com/pecio/simpleapp/pkg/generated/listers --go-header-file hack/boilerplate.go.txt ./api/v1alpha1
+//go:generate go run k8s.io/code-generator/cmd/informer-gen@v0.34.0 --versioned-clientset-package github.com/pecio/simpleapp/pkg/generated/clientset/versioned --listers-package github.com/pecio/simpleapp/pkg/generated/listers --output-dir pkg/generated/informers --output-pkg github.com/pecio/simpleapp/pkg/generated/informers --go-header-file hack/boilerplate.go.txt ./api/v1alpha1
+
 package main
 
 import (
 	"context"
-	"encoding/json"
+	"flag"
 	"log"
 	"os"
 	"time"
 
+	"github.com/pecio/simpleapp/pkg/generated/clientset/versioned"
+	"github.com/pecio/simpleapp/pkg/generated/informers/externalversions"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
@@ -14,6 +22,19 @@ import (
 )
 
 func main() {
+	flag.StringVar(&defaultSecurityProfile, "security-profile", defaultSecurityProfile, "securityContext profile for SimpleApps that do not set one (restricted or none)")
+	flag.BoolVar(&allowHostPath, "allow-host-path", allowHostPath, "allow SimpleApps to mount hostPath volumes")
+	flag.BoolVar(&allowHostNetwork, "allow-host-network", allowHostNetwork, "allow SimpleApps to use the host network")
+	flag.StringVar(&allowedServiceTypes, "allowed-service-types", allowedServiceTypes, "comma separated Service types SimpleApps may use")
+	flag.StringVar(&webhookAddr, "webhook-addr", webhookAddr, "address to serve the admission and conversion webhooks on, empty to disable them")
+	flag.StringVar(&webhookService, "webhook-service", webhookService, "name of the Service of the admission webhooks, for the self-signed certificate")
+	flag.StringVar(&webhookCertFile, "webhook-cert-file", webhookCertFile, "certificate for the admission webhooks, self-signed if not set")
+	flag.StringVar(&webhookKeyFile, "webhook-key-file", webhookKeyFile, "private key of the webhook certificate")
+	flag.Parse()
+	if !validSecurityProfile(defaultSecurityProfile) {
+		log.Fatalf("Unknown security profile %v", defaultSecurityProfile)
+	}
+
 	config, err := rest.InClusterConfig()
 	if err != nil {
 		log.Fatal(err)
@@ -22,6 +43,10 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	appClientset, err := versioned.NewForConfig(config)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// Get our namespace
 	namespaceBytes, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
@@ -31,6 +56,28 @@ func main() {
 	namespace := string(namespaceBytes)
 	log.Printf("Starting SimpleApp controller in namespace %v", namespace)
 
+	if webhookAddr != "" {
+		err = startWebhookServer(clientset, namespace)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		// Without the conversion webhook no version of SimpleApps could be
+		// read but the stored one
+		conversion, err := conversionWebhookEnabled(clientset)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if conversion {
+			log.Fatalf("%v converts versions through the webhook, -webhook-addr is required", crdName)
+		}
+	}
+
+	err = checkStoredVersions(clientset)
+	if err != nil {
+		log.Printf("Got %v checking stored versions of %v", err, crdName)
+	}
+
 	oac := clientset.OpenAPIV3()
 	if oac == nil {
 		log.Fatal("OpenAPI V3 is not available")
@@ -45,20 +92,21 @@ func main() {
 		log.Fatalf("Resource Path for %v not found", resourcePath)
 	}
 
+	// Watch SimpleApps instead of listing them on every pass
+	informerFactory := externalversions.NewSharedInformerFactoryWithOptions(appClientset, 0, externalversions.WithNamespace(namespace))
+	simpleAppLister := informerFactory.Apps().V1alpha1().SimpleApps().Lister()
+	stopCh := make(chan struct{})
+	informerFactory.Start(stopCh)
+	for informerType, synced := range informerFactory.WaitForCacheSync(stopCh) {
+		if !synced {
+			log.Fatalf("Cache of %v failed to sync", informerType)
+		}
+	}
+
 	simpleApps := make(map[string]SimpleApp, 0)
 
 	for {
-		result := clientset.RESTClient().Get().AbsPath("/apis/" + resourcePath).Namespace(namespace).Resource(plural).Do(context.TODO())
-		if result.Error() != nil {
-			log.Fatal(result.Error())
-		}
-		content, err := result.Raw()
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		var simpleAppList SimpleAppList
-		err = json.Unmarshal(content, &simpleAppList)
+		simpleAppList, err := simpleAppLister.SimpleApps(namespace).List(labels.Everything())
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -70,41 +118,71 @@ func main() {
 			log.Fatalf("Got %v listing Deployments", err)
 		}
 
+		// Fetch managed StatefulSets
+		statefulSets, err := clientset.AppsV1().StatefulSets(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
+		if err != nil {
+			log.Fatalf("Got %v listing StatefulSets", err)
+		}
+
+		// Fetch managed DaemonSets
+		daemonSets, err := clientset.AppsV1().DaemonSets(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
+		if err != nil {
+			log.Fatalf("Got %v listing DaemonSets", err)
+		}
+
+		// Fetch managed Jobs and CronJobs
+		jobs, err := clientset.BatchV1().Jobs(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
+		if err != nil {
+			log.Fatalf("Got %v listing Jobs", err)
+		}
+		cronJobs, err := clientset.BatchV1().CronJobs(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
+		if err != nil {
+			log.Fatalf("Got %v listing CronJobs", err)
+		}
+
 		// Fetch managed services
 		services, err := clientset.CoreV1().Services(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
 		if err != nil {
 			log.Fatalf("Got %v listing Services", err)
 		}
 
+		// Fetch managed PersistentVolumeClaims
+		claims, err := clientset.CoreV1().PersistentVolumeClaims(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
+		if err != nil {
+			log.Fatalf("Got %v listing PersistentVolumeClaims", err)
+		}
+
 		// Store the list of previously existing apps
 		oldAppSet := make(map[string]struct{}, len(simpleApps))
 		for name := range simpleApps {
 			oldAppSet[name] = struct{}{}
 		}
 
-		for _, simpleApp := range simpleAppList.Items {
+		for _, item := range simpleAppList {
+			// Objects in the cache are shared and must not be modified
+			simpleApp := SimpleApp{SimpleApp: *item.DeepCopy()}
 			// Does App already exist?
-			_, ok := simpleApps[simpleApp.Metadata.Name]
+			_, ok := simpleApps[simpleApp.Name]
 			if ok {
 				// Remove from oldAppList
-				delete(oldAppSet, simpleApp.Metadata.Name)
+				delete(oldAppSet, simpleApp.Name)
 			} else {
-				log.Printf("SimpleApp %v.%v appeared", simpleApp.Metadata.Namespace, simpleApp.Metadata.Name)
+				log.Printf("SimpleApp %v.%v appeared", simpleApp.Namespace, simpleApp.Name)
 			}
-			err := simpleApp.createOrUpdate(clientset)
+			err := simpleApp.createOrUpdate(clientset, appClientset)
 			if err != nil {
-				log.Printf("Got %v creating or updating %v", err, simpleApp.Metadata.Name)
+				log.Printf("Got %v creating or updating %v", err, simpleApp.Name)
 			}
 			// Store updated SimpleApp
-			simpleApps[simpleApp.Metadata.Name] = simpleApp
+			simpleApps[simpleApp.Name] = simpleApp
 		}
 
 		// Delete no longer existing apps
 		for name := range oldAppSet {
-			log.Printf("SimpleApp %v.%v disappeared", simpleApps[name].Metadata.Namespace, simpleApps[name].Metadata.Name)
+			log.Printf("SimpleApp %v.%v disappeared", simpleApps[name].Namespace, simpleApps[name].Name)
 			err := simpleApps[name].delete(clientset)
 			if err != nil {
-				log.Printf("Got %v deleting %v", err, simpleApps[name].Metadata.Name)
+				log.Printf("Got %v deleting %v", err, simpleApps[name].Name)
 			}
 			delete(simpleApps, name)
 		}
@@ -112,30 +190,116 @@ func main() {
 		// Reap orphan Deployments
 	deployments:
 		for _, deployment := range deployments.Items {
-			for simpleAppName := range simpleApps {
-				if deployment.Name == simpleAppName {
+			for simpleAppName, simpleApp := range simpleApps {
+				if deployment.Name == simpleAppName && simpleApp.workloadType() == workloadDeployment {
 					continue deployments
 				}
 			}
 			log.Printf("Reaping orphan Deployment %v.%v", deployment.Namespace, deployment.Name)
 			err := clientset.AppsV1().Deployments(namespace).Delete(context.TODO(), deployment.Name, metav1.DeleteOptions{})
 			if err != nil {
-				log.Printf("Got %v deleting Deployment %v.%v", deployment.Namespace, deployment.Name)
+				log.Printf("Got %v deleting Deployment %v.%v", err, deployment.Namespace, deployment.Name)
+			}
+		}
+
+		// Reap orphan StatefulSets
+	statefulSets:
+		for _, statefulSet := range statefulSets.Items {
+			for simpleAppName, simpleApp := range simpleApps {
+				if statefulSet.Name == simpleAppName && simpleApp.workloadType() == workloadStatefulSet {
+					continue statefulSets
+				}
+			}
+			log.Printf("Reaping orphan StatefulSet %v.%v", statefulSet.Namespace, statefulSet.Name)
+			err := clientset.AppsV1().StatefulSets(namespace).Delete(context.TODO(), statefulSet.Name, metav1.DeleteOptions{})
+			if err != nil {
+				log.Printf("Got %v deleting StatefulSet %v.%v", err, statefulSet.Namespace, statefulSet.Name)
+			}
+		}
+
+		// Reap orphan DaemonSets
+	daemonSets:
+		for _, daemonSet := range daemonSets.Items {
+			for simpleAppName, simpleApp := range simpleApps {
+				if daemonSet.Name == simpleAppName && simpleApp.workloadType() == workloadDaemonSet {
+					continue daemonSets
+				}
+			}
+			log.Printf("Reaping orphan DaemonSet %v.%v", daemonSet.Namespace, daemonSet.Name)
+			err := clientset.AppsV1().DaemonSets(namespace).Delete(context.TODO(), daemonSet.Name, metav1.DeleteOptions{})
+			if err != nil {
+				log.Printf("Got %v deleting DaemonSet %v.%v", err, daemonSet.Namespace, daemonSet.Name)
+			}
+		}
+
+		// Reap orphan Jobs. Those created by a CronJob go away with it.
+		propagationPolicy := metav1.DeletePropagationBackground
+	jobs:
+		for _, job := range jobs.Items {
+			if metav1.GetControllerOf(&job) != nil {
+				continue
+			}
+			for simpleAppName, simpleApp := range simpleApps {
+				if job.Name == simpleAppName && simpleApp.workloadType() == workloadJob {
+					continue jobs
+				}
+			}
+			log.Printf("Reaping orphan Job %v.%v", job.Namespace, job.Name)
+			err := clientset.BatchV1().Jobs(namespace).Delete(context.TODO(), job.Name, metav1.DeleteOptions{PropagationPolicy: &propagationPolicy})
+			if err != nil {
+				log.Printf("Got %v deleting Job %v.%v", err, job.Namespace, job.Name)
+			}
+		}
+
+		// Reap orphan CronJobs
+	cronJobs:
+		for _, cronJob := range cronJobs.Items {
+			for simpleAppName, simpleApp := range simpleApps {
+				if cronJob.Name == simpleAppName && simpleApp.workloadType() == workloadCronJob {
+					continue cronJobs
+				}
+			}
+			log.Printf("Reaping orphan CronJob %v.%v", cronJob.Namespace, cronJob.Name)
+			err := clientset.BatchV1().CronJobs(namespace).Delete(context.TODO(), cronJob.Name, metav1.DeleteOptions{PropagationPolicy: &propagationPolicy})
+			if err != nil {
+				log.Printf("Got %v deleting CronJob %v.%v", err, cronJob.Namespace, cronJob.Name)
 			}
 		}
 
 		// Reap orphan Services
 	services:
 		for _, service := range services.Items {
-			for simpleAppName := range simpleApps {
-				if service.Name == simpleAppName {
-					continue services
+			for _, simpleApp := range simpleApps {
+				for _, serviceName := range simpleApp.serviceNames() {
+					if service.Name == serviceName {
+						continue services
+					}
 				}
 			}
 			log.Printf("Reaping orphan Service %v.%v", service.Namespace, service.Name)
 			err := clientset.CoreV1().Services(namespace).Delete(context.TODO(), service.Name, metav1.DeleteOptions{})
 			if err != nil {
-				log.Printf("Got %v deleting Service %v.%v", service.Namespace, service.Name)
+				log.Printf("Got %v deleting Service %v.%v", err, service.Namespace, service.Name)
+			}
+		}
+
+		// Reap orphan PersistentVolumeClaims, unless they are to be retained
+	claims:
+		for _, claim := range claims.Items {
+			if claim.Annotations[claimDeletePolicyAnnotation] != claimDeletePolicyDelete {
+				continue
+			}
+			for _, simpleApp := range simpleApps {
+				for _, claimName := range simpleApp.claimNames() {
+					if claim.Name == claimName {
+						continue claims
+					}
+				}
+			}
+			log.Printf("Reaping orphan PersistentVolumeClaim %v.%v", claim.Namespace, claim.Name)
+			err := clientset.CoreV1().PersistentVolumeClaims(namespace).Delete(context.TODO(), claim.Name, metav1.DeleteOptions{})
+			if err != nil {
+				log.Printf("Got %v deleting PersistentVolumeClaim %v.%v", err, claim.Namespace, claim.Name)
 			}
 		}
 
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Whether hostNetwork is allowed, set from the command line
+var allowHostNetwork = false
+
+// Sets the pod-level networking and runtime options of podSpec
+func (sa *SimpleApp) applyPod(podSpec *corev1.PodSpec) error {
+	saPod := sa.Spec.Pod
+	if saPod == nil {
+		return nil
+	}
+	podSpec.DNSPolicy = saPod.DNSPolicy
+	podSpec.DNSConfig = saPod.DNSConfig.DeepCopy()
+	podSpec.HostAliases = saPod.HostAliases
+	podSpec.RuntimeClassName = saPod.RuntimeClassName
+	podSpec.ShareProcessNamespace = saPod.ShareProcessNamespace
+
+	if podSpec.DNSPolicy == corev1.DNSNone && podSpec.DNSConfig == nil {
+		return fmt.Errorf("dnsPolicy None requires dnsConfig in %v.%v", sa.Namespace, sa.Name)
+	}
+
+	if !saPod.HostNetwork {
+		return nil
+	}
+	if !allowHostNetwork {
+		return fmt.Errorf("hostNetwork in %v.%v is not allowed by the controller", sa.Namespace, sa.Name)
+	}
+	podSpec.HostNetwork = true
+	// The API server sets hostPort to containerPort on the host network, do
+	// the same so that the pod template compares equal
+	for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+		for i := range containers {
+			for j := range containers[i].Ports {
+				containers[i].Ports[j].HostPort = containers[i].Ports[j].ContainerPort
+			}
+		}
+	}
+	return nil
+}
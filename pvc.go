@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/pecio/simpleapp/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	claimDeletePolicyRetain = "Retain"
+	claimDeletePolicyDelete = "Delete"
+
+	// Stored on the claims we create, so that orphans can be reaped after
+	// their SimpleApp is gone
+	claimDeletePolicyAnnotation = "apps.raulpedroche.es/delete-policy"
+)
+
+func claimDeletePolicy(c *v1alpha1.SimpleAppPersistentVolumeClaimCreate) string {
+	if c.DeletePolicy == "" {
+		return claimDeletePolicyRetain
+	}
+	return c.DeletePolicy
+}
+
+// Names of the PersistentVolumeClaims created for this SimpleApp
+func (sa *SimpleApp) claimNames() []string {
+	names := make([]string, 0)
+	for _, saVolume := range sa.Spec.Volumes {
+		if saVolume.PersistentVolumeClaim != nil && saVolume.PersistentVolumeClaim.Create != nil {
+			names = append(names, saVolume.PersistentVolumeClaim.ClaimName)
+		}
+	}
+	return names
+}
+
+func (sa *SimpleApp) buildPersistentVolumeClaims() ([]corev1.PersistentVolumeClaim, error) {
+	claims := make([]corev1.PersistentVolumeClaim, 0)
+	names := make(map[string]struct{})
+	for _, saVolume := range sa.Spec.Volumes {
+		if saVolume.PersistentVolumeClaim == nil || saVolume.PersistentVolumeClaim.Create == nil {
+			continue
+		}
+		claimName := saVolume.PersistentVolumeClaim.ClaimName
+		if _, ok := names[claimName]; ok {
+			return nil, fmt.Errorf("PersistentVolumeClaim %v is created more than once in %v.%v", claimName, sa.Namespace, sa.Name)
+		}
+		names[claimName] = struct{}{}
+
+		create := saVolume.PersistentVolumeClaim.Create
+		switch claimDeletePolicy(create) {
+		case claimDeletePolicyRetain, claimDeletePolicyDelete:
+		default:
+			return nil, fmt.Errorf("unknown deletePolicy %v for PersistentVolumeClaim %v in %v.%v", create.DeletePolicy, claimName, sa.Namespace, sa.Name)
+		}
+		spec := buildClaimSpec(&create.SimpleAppVolumeClaimTemplate)
+		spec.VolumeMode = create.VolumeMode
+		claims = append(claims, corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   claimName,
+				Labels: sa.labels(),
+				Annotations: map[string]string{
+					claimDeletePolicyAnnotation: claimDeletePolicy(create),
+				},
+			},
+			Spec: spec,
+		})
+	}
+	return claims, nil
+}
+
+// Only the requested size of an existing claim can be changed, and only to
+// grow it. Other changes are logged and ignored.
+func reconcilePersistentVolumeClaim(clientset *kubernetes.Clientset, namespace string, newClaim corev1.PersistentVolumeClaim) error {
+	// Check if PersistentVolumeClaim exists
+	oldClaim, err := clientset.CoreV1().PersistentVolumeClaims(namespace).Get(context.TODO(), newClaim.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = clientset.CoreV1().PersistentVolumeClaims(namespace).Create(context.TODO(), &newClaim, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		log.Printf("Created PersistentVolumeClaim %v.%v", namespace, newClaim.Name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// Check if PersistentVolumeClaim is ours
+	managedBy, ok := oldClaim.ObjectMeta.Labels[managedByLabel]
+	if !ok || managedBy != managedByValue {
+		return fmt.Errorf("found PersistentVolumeClaim %v.%v not managed by us", oldClaim.ObjectMeta.Namespace, oldClaim.ObjectMeta.Name)
+	}
+
+	updatedClaim := oldClaim.DeepCopy()
+	updatedClaim.Labels = newClaim.Labels
+	if updatedClaim.Annotations == nil {
+		updatedClaim.Annotations = make(map[string]string)
+	}
+	updatedClaim.Annotations[claimDeletePolicyAnnotation] = newClaim.Annotations[claimDeletePolicyAnnotation]
+
+	newSize := newClaim.Spec.Resources.Requests[corev1.ResourceStorage]
+	oldSize := oldClaim.Spec.Resources.Requests[corev1.ResourceStorage]
+	switch newSize.Cmp(oldSize) {
+	case 1:
+		updatedClaim.Spec.Resources.Requests[corev1.ResourceStorage] = newSize
+	case -1:
+		log.Printf("PersistentVolumeClaim %v.%v cannot shrink from %v to %v, keeping its size", oldClaim.ObjectMeta.Namespace, oldClaim.ObjectMeta.Name, oldSize.String(), newSize.String())
+	}
+	if newClaim.Spec.StorageClassName != nil && (oldClaim.Spec.StorageClassName == nil || *oldClaim.Spec.StorageClassName != *newClaim.Spec.StorageClassName) {
+		log.Printf("storageClassName of PersistentVolumeClaim %v.%v cannot be changed, keeping existing one", oldClaim.ObjectMeta.Namespace, oldClaim.ObjectMeta.Name)
+	}
+	if !equality.Semantic.DeepEqual(newClaim.Spec.AccessModes, oldClaim.Spec.AccessModes) {
+		log.Printf("accessModes of PersistentVolumeClaim %v.%v cannot be changed, keeping existing ones", oldClaim.ObjectMeta.Namespace, oldClaim.ObjectMeta.Name)
+	}
+	if newClaim.Spec.VolumeMode != nil && (oldClaim.Spec.VolumeMode == nil || *oldClaim.Spec.VolumeMode != *newClaim.Spec.VolumeMode) {
+		log.Printf("volumeMode of PersistentVolumeClaim %v.%v cannot be changed, keeping existing one", oldClaim.ObjectMeta.Namespace, oldClaim.ObjectMeta.Name)
+	}
+
+	if !equality.Semantic.DeepEqual(updatedClaim, oldClaim) {
+		_, err = clientset.CoreV1().PersistentVolumeClaims(oldClaim.ObjectMeta.Namespace).Update(context.TODO(), updatedClaim, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		log.Printf("PersistentVolumeClaim %v.%v updated", oldClaim.ObjectMeta.Namespace, oldClaim.ObjectMeta.Name)
+	}
+	return nil
+}
+
+// Deletes the claim if it was created with deletePolicy Delete
+func deletePersistentVolumeClaim(clientset *kubernetes.Clientset, namespace, name string) error {
+	// Get current PersistentVolumeClaim
+	oldClaim, err := clientset.CoreV1().PersistentVolumeClaims(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		log.Printf("PersistentVolumeClaim %v.%v already deleted", namespace, name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	managedBy, ok := oldClaim.Labels[managedByLabel]
+	if !ok || managedBy != managedByValue {
+		return fmt.Errorf("found PersistentVolumeClaim %v.%v not managed by us", oldClaim.ObjectMeta.Namespace, oldClaim.ObjectMeta.Name)
+	}
+	if oldClaim.Annotations[claimDeletePolicyAnnotation] != claimDeletePolicyDelete {
+		log.Printf("Keeping PersistentVolumeClaim %v.%v", namespace, name)
+		return nil
+	}
+	err = clientset.CoreV1().PersistentVolumeClaims(oldClaim.ObjectMeta.Namespace).Delete(context.TODO(), oldClaim.ObjectMeta.Name, metav1.DeleteOptions{})
+	if err != nil {
+		return err
+	}
+	log.Printf("Deleted PersistentVolumeClaim %v.%v", namespace, name)
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The annotation set by kubectl rollout restart
+const restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// Annotations of the pods. Changing restartedAt changes the pod template, so
+// that the workload replaces its pods.
+func (sa *SimpleApp) podAnnotations() map[string]string {
+	if sa.Spec.RestartedAt == nil {
+		return sa.Spec.PodAnnotations
+	}
+	annotations := make(map[string]string, len(sa.Spec.PodAnnotations)+1)
+	for key, value := range sa.Spec.PodAnnotations {
+		annotations[key] = value
+	}
+	annotations[restartedAtAnnotation] = sa.Spec.RestartedAt.UTC().Format(time.RFC3339)
+	return annotations
+}
+
+// Time of the last restart rolled out to a workload, from the annotation of
+// its pod template
+func lastRestartedAt(template metav1.ObjectMeta) *metav1.Time {
+	value, ok := template.Annotations[restartedAtAnnotation]
+	if !ok {
+		return nil
+	}
+	restartedAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return &metav1.Time{Time: restartedAt}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	spreadZone = "zone"
+	spreadHost = "host"
+)
+
+// Sets the placement fields of podSpec. The spread shorthand is rendered as a
+// topology spread constraint plus a preferred anti-affinity term, both
+// selecting the pods of this SimpleApp.
+func (sa *SimpleApp) applyScheduling(podSpec *corev1.PodSpec) error {
+	scheduling := sa.Spec.Scheduling
+	if scheduling == nil {
+		return nil
+	}
+	podSpec.NodeSelector = scheduling.NodeSelector
+	podSpec.Tolerations = scheduling.Tolerations
+	podSpec.PriorityClassName = scheduling.PriorityClassName
+	podSpec.TopologySpreadConstraints = scheduling.TopologySpreadConstraints
+	if scheduling.Affinity != nil {
+		podSpec.Affinity = scheduling.Affinity.DeepCopy()
+	}
+
+	if scheduling.Spread == "" {
+		return nil
+	}
+	var topologyKey string
+	switch scheduling.Spread {
+	case spreadZone:
+		topologyKey = corev1.LabelTopologyZone
+	case spreadHost:
+		topologyKey = corev1.LabelHostname
+	default:
+		return fmt.Errorf("unknown spread %v in %v.%v", scheduling.Spread, sa.Namespace, sa.Name)
+	}
+
+	constraint := corev1.TopologySpreadConstraint{
+		MaxSkew:           1,
+		TopologyKey:       topologyKey,
+		WhenUnsatisfiable: corev1.ScheduleAnyway,
+		LabelSelector:     sa.selector(),
+	}
+	podSpec.TopologySpreadConstraints = append(append([]corev1.TopologySpreadConstraint{}, podSpec.TopologySpreadConstraints...), constraint)
+
+	if podSpec.Affinity == nil {
+		podSpec.Affinity = &corev1.Affinity{}
+	}
+	if podSpec.Affinity.PodAntiAffinity == nil {
+		podSpec.Affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+	}
+	podSpec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(podSpec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, corev1.WeightedPodAffinityTerm{
+		Weight: 100,
+		PodAffinityTerm: corev1.PodAffinityTerm{
+			LabelSelector: sa.selector(),
+			TopologyKey:   topologyKey,
+		},
+	})
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	securityProfileRestricted = "restricted"
+	securityProfileNone       = "none"
+)
+
+// Profile applied to SimpleApps that do not choose one, set from the command line
+var defaultSecurityProfile = securityProfileRestricted
+
+func validSecurityProfile(profile string) bool {
+	return profile == securityProfileRestricted || profile == securityProfileNone
+}
+
+func (sa *SimpleApp) securityProfile() (string, error) {
+	if sa.Spec.SecurityContext == nil || sa.Spec.SecurityContext.Profile == "" {
+		return defaultSecurityProfile, nil
+	}
+	if !validSecurityProfile(sa.Spec.SecurityContext.Profile) {
+		return "", fmt.Errorf("unknown security profile %v in %v.%v", sa.Spec.SecurityContext.Profile, sa.Namespace, sa.Name)
+	}
+	return sa.Spec.SecurityContext.Profile, nil
+}
+
+// Builds the Pod and Container security contexts. The restricted profile
+// provides values compliant with the "restricted" Pod Security Standard, and
+// anything explicitly set in the SimpleApp overrides them, except for the
+// dropped capabilities.
+func (sa *SimpleApp) buildSecurityContexts() (*corev1.PodSecurityContext, *corev1.SecurityContext, error) {
+	profile, err := sa.securityProfile()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var podSecurityContext *corev1.PodSecurityContext
+	var securityContext *corev1.SecurityContext
+	if profile == securityProfileRestricted {
+		runAsNonRoot := true
+		allowPrivilegeEscalation := false
+		podSecurityContext = &corev1.PodSecurityContext{
+			RunAsNonRoot:   &runAsNonRoot,
+			SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+		}
+		securityContext = &corev1.SecurityContext{
+			AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+			Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+		}
+	}
+
+	saSecurityContext := sa.Spec.SecurityContext
+	if saSecurityContext == nil {
+		return podSecurityContext, securityContext, nil
+	}
+	if podSecurityContext == nil {
+		podSecurityContext = &corev1.PodSecurityContext{}
+	}
+	if securityContext == nil {
+		securityContext = &corev1.SecurityContext{}
+	}
+	if saSecurityContext.RunAsUser != nil {
+		podSecurityContext.RunAsUser = saSecurityContext.RunAsUser
+	}
+	if saSecurityContext.RunAsGroup != nil {
+		podSecurityContext.RunAsGroup = saSecurityContext.RunAsGroup
+	}
+	if saSecurityContext.RunAsNonRoot != nil {
+		podSecurityContext.RunAsNonRoot = saSecurityContext.RunAsNonRoot
+	}
+	if saSecurityContext.FSGroup != nil {
+		podSecurityContext.FSGroup = saSecurityContext.FSGroup
+	}
+	if saSecurityContext.SeccompProfile != nil {
+		podSecurityContext.SeccompProfile = saSecurityContext.SeccompProfile
+	}
+	if saSecurityContext.ReadOnlyRootFilesystem != nil {
+		securityContext.ReadOnlyRootFilesystem = saSecurityContext.ReadOnlyRootFilesystem
+	}
+	if saSecurityContext.AllowPrivilegeEscalation != nil {
+		securityContext.AllowPrivilegeEscalation = saSecurityContext.AllowPrivilegeEscalation
+	}
+	if saSecurityContext.Capabilities != nil {
+		if profile == securityProfileRestricted {
+			// The restricted Pod Security Standard requires dropping ALL,
+			// so capabilities are only added on top of it
+			securityContext.Capabilities = &corev1.Capabilities{
+				Add:  saSecurityContext.Capabilities.Add,
+				Drop: securityContext.Capabilities.Drop,
+			}
+		} else {
+			securityContext.Capabilities = saSecurityContext.Capabilities
+		}
+	}
+
+	// Do not render empty security contexts
+	if reflect.DeepEqual(*podSecurityContext, corev1.PodSecurityContext{}) {
+		podSecurityContext = nil
+	}
+	if reflect.DeepEqual(*securityContext, corev1.SecurityContext{}) {
+		securityContext = nil
+	}
+	return podSecurityContext, securityContext, nil
+}
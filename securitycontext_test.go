@@ -0,0 +1,83 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestBuildSecurityContexts(t *testing.T) {
+	defer func(profile string) { defaultSecurityProfile = profile }(defaultSecurityProfile)
+	yes, no := true, false
+	user := int64(1000)
+	runtimeDefault := &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+	dropAll := &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}}
+
+	tests := []struct {
+		name           string
+		defaultProfile string
+		spec           string
+		wantPod        *corev1.PodSecurityContext
+		wantContainer  *corev1.SecurityContext
+	}{
+		{
+			name:           "restricted by default",
+			defaultProfile: securityProfileRestricted,
+			spec:           `{"image": "nginx:1.27"}`,
+			wantPod:        &corev1.PodSecurityContext{RunAsNonRoot: &yes, SeccompProfile: runtimeDefault},
+			wantContainer:  &corev1.SecurityContext{AllowPrivilegeEscalation: &no, Capabilities: dropAll},
+		},
+		{
+			name:           "nothing by default",
+			defaultProfile: securityProfileNone,
+			spec:           `{"image": "nginx:1.27"}`,
+		},
+		{
+			name:           "restricted with overrides",
+			defaultProfile: securityProfileNone,
+			spec:           `{"image": "nginx:1.27", "securityContext": {"profile": "restricted", "runAsUser": 1000, "readOnlyRootFilesystem": true}}`,
+			wantPod:        &corev1.PodSecurityContext{RunAsUser: &user, RunAsNonRoot: &yes, SeccompProfile: runtimeDefault},
+			wantContainer:  &corev1.SecurityContext{AllowPrivilegeEscalation: &no, Capabilities: dropAll, ReadOnlyRootFilesystem: &yes},
+		},
+		{
+			name:           "restricted keeps dropping ALL",
+			defaultProfile: securityProfileRestricted,
+			spec:           `{"image": "nginx:1.27", "securityContext": {"capabilities": {"add": ["NET_BIND_SERVICE"], "drop": ["NET_RAW"]}}}`,
+			wantPod:        &corev1.PodSecurityContext{RunAsNonRoot: &yes, SeccompProfile: runtimeDefault},
+			wantContainer: &corev1.SecurityContext{
+				AllowPrivilegeEscalation: &no,
+				Capabilities:             &corev1.Capabilities{Add: []corev1.Capability{"NET_BIND_SERVICE"}, Drop: []corev1.Capability{"ALL"}},
+			},
+		},
+		{
+			name:           "capabilities as given without a profile",
+			defaultProfile: securityProfileRestricted,
+			spec:           `{"image": "nginx:1.27", "securityContext": {"profile": "none", "capabilities": {"drop": ["NET_RAW"]}}}`,
+			wantContainer:  &corev1.SecurityContext{Capabilities: &corev1.Capabilities{Drop: []corev1.Capability{"NET_RAW"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defaultSecurityProfile = tt.defaultProfile
+			sa := testSimpleApp(t, tt.spec)
+			template, err := sa.buildPodTemplate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(template.Spec.SecurityContext, tt.wantPod) {
+				t.Errorf("pod securityContext = %v, want %v", template.Spec.SecurityContext, tt.wantPod)
+			}
+			if got := template.Spec.Containers[0].SecurityContext; !reflect.DeepEqual(got, tt.wantContainer) {
+				t.Errorf("container securityContext = %v, want %v", got, tt.wantContainer)
+			}
+		})
+	}
+
+	defaultSecurityProfile = securityProfileRestricted
+	sa := testSimpleApp(t, `{"image": "nginx:1.27", "securityContext": {"profile": "baseline"}}`)
+	_, _, err := sa.buildSecurityContexts()
+	if err == nil {
+		t.Error("buildSecurityContexts() accepted an unknown profile")
+	}
+}
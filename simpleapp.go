@@ -2,17 +2,19 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"hash/crc32"
 	"log"
+	"maps"
+	"slices"
 	"strings"
 
+	"github.com/pecio/simpleapp/api/v1alpha1"
+	"github.com/pecio/simpleapp/pkg/generated/clientset/versioned"
 	"github.com/pecio/simpleapp/utils"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/rand"
@@ -24,87 +26,138 @@ const (
 	managedByValue = "simpleapp"
 	resourcePath   = "apps.raulpedroche.es/v1alpha1"
 	singular       = "SimpleApp"
-	plural         = "SimpleApps"
-)
-
-type SimpleAppList struct {
-	ApiVersion string      `json:"apiVersion"`
-	Items      []SimpleApp `json:"items"`
-	Kind       string      `json:"kind"`
-	Metadata   interface{} `json:"metadata"`
-}
-
-type SimpleApp struct {
-	ApiVersion string `json:"apiVersion"`
-	Kind       string `json:"kind"`
 
-	Metadata metav1.ObjectMeta `json:"metadata"`
-	Spec     simpleAppSpec     `json:"spec,omitempty"`
-}
+	workloadDeployment  = "Deployment"
+	workloadStatefulSet = "StatefulSet"
+	workloadJob         = "Job"
+	workloadCronJob     = "CronJob"
+	workloadDaemonSet   = "DaemonSet"
+)
 
-type simpleAppSpec struct {
-	Image       string             `json:"image"`
-	Replicas    *int32             `json:"replicas,omitempty"`
-	ServiceType corev1.ServiceType `json:"serviceType"`
-	Ports       []simpleAppPort    `json:"ports,omitempty"`
-	Env         []corev1.EnvVar    `json:"env,omitempty"`
-	Volumes     []simpleAppVolume  `json:"volumes,omitempty"`
-}
+// Stored on the Services we create, listing the annotation keys that come from
+// the SimpleApp so that others are left alone
+const managedAnnotationsAnnotation = "apps.raulpedroche.es/managed-annotations"
 
-type simpleAppPort struct {
-	Name          string          `json:"name,omitempty"`
-	HostPort      int32           `json:"hostPort"`
-	ContainerPort int32           `json:"containerPort"`
-	Protocol      corev1.Protocol `json:"protocol,omitempty"`
-}
+// Whether hostPath volumes are allowed, set from the command line
+var allowHostPath = false
 
-type simpleAppVolume struct {
-	MountPath             string                                `json:"mountPath"`
-	EmptyDir              *simpleAppVolumeEmptyDir              `json:"emptyDir,omitempty"`
-	ConfigMap             *simpleAppVolumeConfigMapOrSecret     `json:"configMap,omitempty"`
-	PersistentVolumeClaim *simpleAppVolumePersistentVolumeClaim `json:"persistentVolumeClaim,omitempty"`
-	Secret                *simpleAppVolumeConfigMapOrSecret     `json:"secret,omitempty"`
-	CSI                   *corev1.CSIVolumeSource               `json:"csi,omitempty"`
-}
+type SimpleApp struct {
+	v1alpha1.SimpleApp
 
-type simpleAppVolumeEmptyDir struct {
-	Medium    corev1.StorageMedium `json:"medium,omitempty"`
-	SizeLimit *resource.Quantity   `json:"sizeLimit,omitempty"`
+	// Whether the existing workload uses the selector from before the
+	// recommended labels
+	legacySelector bool
 }
 
-type simpleAppVolumeConfigMapOrSecret struct {
-	DefaultMode *int32             `json:"defaultMode,omitempty"`
-	Items       []corev1.KeyToPath `json:"items,omitempty"`
-	Name        string             `json:"name"`
-	Optional    *bool              `json:"optional,omitempty"`
-}
+func (sa *SimpleApp) createOrUpdate(clientset *kubernetes.Clientset, appClientset versioned.Interface) error {
+	// SimpleApps admitted before the webhooks were installed may be invalid
+	errs := sa.checkSpec()
+	if len(errs) > 0 {
+		return fmt.Errorf("SimpleApp %v.%v is invalid: %v", sa.Namespace, sa.Name, strings.Join(errs, "; "))
+	}
 
-type simpleAppVolumePersistentVolumeClaim struct {
-	ClaimName string `json:"claimName"`
-	ReadOnly  *bool  `json:"readOnly,omitempty"`
-}
+	migrating, err := sa.detectLegacySelector(clientset)
+	if err != nil {
+		return err
+	}
+	if migrating {
+		// Wait for the old workload to go away
+		return nil
+	}
 
-func (sa *SimpleApp) createOrUpdate(clientset *kubernetes.Clientset) error {
-	// Sanity checks - fix duplicate Volumes or Ports
-	fv := sa.fixVolumes()
-	fp := sa.fixPorts()
-	if fv || fp {
-		sa.updateApp(clientset)
+	// Claims are created before the pods that mount them
+	claims, err := sa.buildPersistentVolumeClaims()
+	if err != nil {
+		return err
+	}
+	for _, claim := range claims {
+		err = reconcilePersistentVolumeClaim(clientset, sa.Namespace, claim)
+		if err != nil {
+			return err
+		}
 	}
 
-	// Check if Deployment exists
-	oldDeployment, err := clientset.AppsV1().Deployments(sa.Metadata.Namespace).Get(context.TODO(), sa.Metadata.Name, metav1.GetOptions{})
-	if errors.IsNotFound(err) {
+	switch sa.workloadType() {
+	case workloadDeployment:
 		deployment, err := sa.buildDeployment()
 		if err != nil {
 			return err
 		}
+		err = reconcileDeployment(clientset, sa.Namespace, deployment)
+		if err != nil {
+			return err
+		}
+	case workloadStatefulSet:
+		headlessService, err := sa.buildHeadlessService()
+		if err != nil {
+			return err
+		}
+		err = reconcileService(clientset, sa.Namespace, headlessService)
+		if err != nil {
+			return err
+		}
+		statefulSet, err := sa.buildStatefulSet()
+		if err != nil {
+			return err
+		}
+		err = reconcileStatefulSet(clientset, sa.Namespace, statefulSet)
+		if err != nil {
+			return err
+		}
+	case workloadDaemonSet:
+		daemonSet, err := sa.buildDaemonSet()
+		if err != nil {
+			return err
+		}
+		err = reconcileDaemonSet(clientset, sa.Namespace, daemonSet)
+		if err != nil {
+			return err
+		}
+	case workloadJob:
+		job, err := sa.buildJob()
+		if err != nil {
+			return err
+		}
+		err = sa.reconcileJob(clientset, appClientset, job)
+		if err != nil {
+			return err
+		}
+	case workloadCronJob:
+		cronJob, err := sa.buildCronJob()
+		if err != nil {
+			return err
+		}
+		err = reconcileCronJob(clientset, sa.Namespace, cronJob)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown workloadType %v in %v.%v", sa.Spec.WorkloadType, sa.Namespace, sa.Name)
+	}
+
+	services, err := sa.buildServices()
+	if err != nil {
+		return err
+	}
+	for _, service := range services {
+		err = reconcileService(clientset, sa.Namespace, service)
+		if err != nil {
+			return err
+		}
+	}
+
+	return sa.updateStatus(clientset, appClientset)
+}
 
-		_, err = clientset.AppsV1().Deployments(sa.Metadata.Namespace).Create(context.TODO(), &deployment, metav1.CreateOptions{})
+func reconcileDeployment(clientset *kubernetes.Clientset, namespace string, newDeployment appsv1.Deployment) error {
+	// Check if Deployment exists
+	oldDeployment, err := clientset.AppsV1().Deployments(namespace).Get(context.TODO(), newDeployment.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = clientset.AppsV1().Deployments(namespace).Create(context.TODO(), &newDeployment, metav1.CreateOptions{})
 		if err != nil {
 			return err
 		}
-		log.Printf("Created Deployment %v.%v", sa.Metadata.Namespace, sa.Metadata.Name)
+		log.Printf("Created Deployment %v.%v", namespace, newDeployment.Name)
 	} else if err != nil {
 		return err
 	} else {
@@ -114,11 +167,6 @@ func (sa *SimpleApp) createOrUpdate(clientset *kubernetes.Clientset) error {
 			return fmt.Errorf("found Deployment %v.%v not managed by us", oldDeployment.ObjectMeta.Namespace, oldDeployment.ObjectMeta.Name)
 		}
 
-		newDeployment, err := sa.buildDeployment()
-		if err != nil {
-			return err
-		}
-
 		if !utils.DeploymentEqual(newDeployment, *oldDeployment) {
 			_, err = clientset.AppsV1().Deployments(oldDeployment.ObjectMeta.Namespace).Update(context.TODO(), &newDeployment, metav1.UpdateOptions{})
 			if err != nil {
@@ -127,17 +175,15 @@ func (sa *SimpleApp) createOrUpdate(clientset *kubernetes.Clientset) error {
 			log.Printf("Deployment %v.%v updated", oldDeployment.ObjectMeta.Namespace, oldDeployment.ObjectMeta.Name)
 		}
 	}
+	return nil
+}
 
+func reconcileService(clientset kubernetes.Interface, namespace string, service corev1.Service) error {
 	// Check if Service exists
-	oldService, err := clientset.CoreV1().Services(sa.Metadata.Namespace).Get(context.TODO(), sa.Metadata.Name, metav1.GetOptions{})
+	oldService, err := clientset.CoreV1().Services(namespace).Get(context.TODO(), service.Name, metav1.GetOptions{})
 	if errors.IsNotFound(err) {
 		// Create Service
-		service, err := sa.buildService()
-		if err != nil {
-			return err
-		}
-
-		newService, err := clientset.CoreV1().Services(sa.Metadata.Namespace).Create(context.TODO(), &service, metav1.CreateOptions{})
+		newService, err := clientset.CoreV1().Services(namespace).Create(context.TODO(), &service, metav1.CreateOptions{})
 		if err != nil {
 			return err
 		}
@@ -149,14 +195,28 @@ func (sa *SimpleApp) createOrUpdate(clientset *kubernetes.Clientset) error {
 		if !ok || managedBy != managedByValue {
 			return fmt.Errorf("found Service %v.%v not managed by us", oldService.ObjectMeta.Namespace, oldService.ObjectMeta.Name)
 		}
+		// Named Services of one SimpleApp can clash with those of another
+		if owner := instanceOf(oldService.ObjectMeta.Labels); owner != service.ObjectMeta.Labels[instanceLabel] {
+			return fmt.Errorf("found Service %v.%v owned by SimpleApp %v", oldService.ObjectMeta.Namespace, oldService.ObjectMeta.Name, owner)
+		}
 
-		newService, err := sa.buildService()
-		if err != nil {
+		// Switching between headless and not requires a new Service
+		if !utils.ServiceHeadlessEqual(service, *oldService) {
+			err = deleteService(clientset, namespace, oldService.ObjectMeta.Name, service.ObjectMeta.Labels[instanceLabel])
+			if err != nil {
+				return err
+			}
+			newService, err := clientset.CoreV1().Services(namespace).Create(context.TODO(), &service, metav1.CreateOptions{})
+			if err != nil {
+				return err
+			}
+			log.Printf("Created Service %v.%v", newService.ObjectMeta.Namespace, newService.ObjectMeta.Name)
 			return nil
 		}
 
-		if !utils.ServicesEqual(newService, *oldService) {
-			_, err = clientset.CoreV1().Services(oldService.ObjectMeta.Namespace).Update(context.TODO(), &newService, metav1.UpdateOptions{})
+		service.ObjectMeta.Annotations = mergeServiceAnnotations(oldService.ObjectMeta.Annotations, service.ObjectMeta.Annotations)
+		if !utils.ServicesEqual(service, *oldService) {
+			_, err = clientset.CoreV1().Services(oldService.ObjectMeta.Namespace).Update(context.TODO(), &service, metav1.UpdateOptions{})
 			if err != nil {
 				return err
 			}
@@ -166,13 +226,37 @@ func (sa *SimpleApp) createOrUpdate(clientset *kubernetes.Clientset) error {
 	return nil
 }
 
-func (sa *SimpleApp) buildService() (corev1.Service, error) {
-	servicePorts := make([]corev1.ServicePort, 0, len(sa.Spec.Ports))
+func (sa *SimpleApp) workloadType() string {
+	if sa.Spec.WorkloadType == "" {
+		return workloadDeployment
+	}
+	return sa.Spec.WorkloadType
+}
 
-	for _, saPort := range sa.Spec.Ports {
+// Names of the Services this SimpleApp should own
+func (sa *SimpleApp) serviceNames() []string {
+	names := make([]string, 0)
+	if sa.serviceEnabled() {
+		names = append(names, sa.Name)
+	}
+	for _, saNamedService := range sa.Spec.Services {
+		if isEnabled(&saNamedService.SimpleAppService) {
+			names = append(names, sa.namedServiceName(saNamedService.Name))
+		}
+	}
+	if sa.workloadType() == workloadStatefulSet {
+		names = append(names, sa.headlessServiceName())
+	}
+	return names
+}
+
+func buildServicePorts(ports []v1alpha1.SimpleAppPort) []corev1.ServicePort {
+	servicePorts := make([]corev1.ServicePort, 0, len(ports))
+
+	for _, saPort := range ports {
 		// Spec forces non-empty names if more than 1 port defined
 		portName := fmt.Sprintf("u-%.13v", saPort.Name)
-		if len(sa.Spec.Ports) > 1 && saPort.Name == "" {
+		if len(ports) > 1 && saPort.Name == "" {
 			if saPort.Protocol == "" {
 				portName = fmt.Sprintf("a-tcp-%d-%d", saPort.HostPort, saPort.ContainerPort)
 			} else {
@@ -185,33 +269,164 @@ func (sa *SimpleApp) buildService() (corev1.Service, error) {
 			Protocol:   saPort.Protocol,
 			Port:       saPort.HostPort,
 			TargetPort: intstr.FromInt32(saPort.ContainerPort),
+			NodePort:   saPort.NodePort,
 		}
 		servicePorts = append(servicePorts, servicePort)
 	}
+	return servicePorts
+}
+
+func (sa *SimpleApp) buildService() (corev1.Service, error) {
+	return sa.buildNamedService(sa.Name, sa.Spec.ServiceType, sa.Spec.Service, sa.Spec.Ports)
+}
+
+// Builds all Services exposing this SimpleApp, the main one named after it and
+// the additional ones from services
+func (sa *SimpleApp) buildServices() ([]corev1.Service, error) {
+	services := make([]corev1.Service, 0, 1+len(sa.Spec.Services))
+	if sa.serviceEnabled() {
+		service, err := sa.buildService()
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, service)
+	}
+
+	for _, saNamedService := range sa.Spec.Services {
+		if !isEnabled(&saNamedService.SimpleAppService) {
+			continue
+		}
+		if saNamedService.Name == "headless" {
+			return nil, fmt.Errorf("service name %v is reserved in %v.%v", saNamedService.Name, sa.Namespace, sa.Name)
+		}
+		ports := sa.Spec.Ports
+		if len(saNamedService.Ports) > 0 {
+			ports = make([]v1alpha1.SimpleAppPort, 0, len(saNamedService.Ports))
+		outer:
+			for _, portName := range saNamedService.Ports {
+				for _, saPort := range sa.Spec.Ports {
+					if saPort.Name == portName {
+						ports = append(ports, saPort)
+						continue outer
+					}
+				}
+				return nil, fmt.Errorf("service %v in %v.%v references unknown port %v", saNamedService.Name, sa.Namespace, sa.Name, portName)
+			}
+		}
+		if len(ports) == 0 {
+			return nil, fmt.Errorf("service %v in %v.%v has no ports", saNamedService.Name, sa.Namespace, sa.Name)
+		}
+		service, err := sa.buildNamedService(sa.namedServiceName(saNamedService.Name), saNamedService.Type, &saNamedService.SimpleAppService, ports)
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+func (sa *SimpleApp) buildNamedService(name string, serviceType corev1.ServiceType, saService *v1alpha1.SimpleAppService, ports []v1alpha1.SimpleAppPort) (corev1.Service, error) {
 	service := corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Namespace: sa.Metadata.Namespace,
-			Name:      sa.Metadata.Name,
+			Namespace: sa.Namespace,
+			Name:      name,
 			Labels:    sa.labels(),
 		},
 		Spec: corev1.ServiceSpec{
-			Selector: sa.labels(),
-			Ports:    servicePorts,
-			Type:     sa.Spec.ServiceType,
+			Selector: sa.selectorLabels(),
+			Ports:    buildServicePorts(ports),
+			Type:     serviceType,
 		},
 	}
+
+	if !serviceTypeAllowed(service.Spec.Type) {
+		return corev1.Service{}, fmt.Errorf("type %v of Service %v is not allowed in %v.%v", service.Spec.Type, name, sa.Namespace, sa.Name)
+	}
+
+	// Pinned node ports only apply to Services that have them
+	nodePorts := service.Spec.Type == corev1.ServiceTypeNodePort || service.Spec.Type == corev1.ServiceTypeLoadBalancer
+	if !nodePorts {
+		for i := range service.Spec.Ports {
+			service.Spec.Ports[i].NodePort = 0
+		}
+	}
+
+	if saService == nil {
+		return service, nil
+	}
+	if saService.ClusterIP != "" {
+		if saService.ClusterIP != corev1.ClusterIPNone {
+			return corev1.Service{}, fmt.Errorf("clusterIP of Service %v can only be None in %v.%v", name, sa.Namespace, sa.Name)
+		}
+		if service.Spec.Type != "" && service.Spec.Type != corev1.ServiceTypeClusterIP {
+			return corev1.Service{}, fmt.Errorf("headless Service %v requires type ClusterIP in %v.%v", name, sa.Namespace, sa.Name)
+		}
+		service.Spec.ClusterIP = saService.ClusterIP
+	}
+	service.ObjectMeta.Annotations = serviceAnnotations(saService.Annotations)
+	service.Spec.SessionAffinity = saService.SessionAffinity
+	if saService.SessionAffinityTimeoutSeconds != nil {
+		service.Spec.SessionAffinityConfig = &corev1.SessionAffinityConfig{
+			ClientIP: &corev1.ClientIPConfig{TimeoutSeconds: saService.SessionAffinityTimeoutSeconds},
+		}
+	}
+	if saService.ExternalTrafficPolicy != "" && !nodePorts {
+		return corev1.Service{}, fmt.Errorf("externalTrafficPolicy of Service %v requires type NodePort or LoadBalancer in %v.%v", name, sa.Namespace, sa.Name)
+	}
+	service.Spec.ExternalTrafficPolicy = saService.ExternalTrafficPolicy
+	service.Spec.InternalTrafficPolicy = saService.InternalTrafficPolicy
+	if (saService.LoadBalancerClass != nil || len(saService.LoadBalancerSourceRanges) > 0) && service.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		return corev1.Service{}, fmt.Errorf("loadBalancerClass and loadBalancerSourceRanges of Service %v require type LoadBalancer in %v.%v", name, sa.Namespace, sa.Name)
+	}
+	service.Spec.LoadBalancerClass = saService.LoadBalancerClass
+	service.Spec.LoadBalancerSourceRanges = saService.LoadBalancerSourceRanges
 	return service, nil
 }
 
-func (sa *SimpleApp) labels() map[string]string {
-	labels := map[string]string{
-		"app":          sa.Metadata.Name,
-		managedByLabel: managedByValue,
+// Annotations from the spec, plus the list of their keys
+func serviceAnnotations(annotations map[string]string) map[string]string {
+	if len(annotations) == 0 {
+		return nil
 	}
-	return labels
+	result := maps.Clone(annotations)
+	result[managedAnnotationsAnnotation] = strings.Join(slices.Sorted(maps.Keys(annotations)), ",")
+	return result
 }
 
-func (sa *SimpleApp) buildDeployment() (appsv1.Deployment, error) {
+// Replaces the annotations we set on an existing Service with the desired
+// ones, keeping those added by others
+func mergeServiceAnnotations(current, desired map[string]string) map[string]string {
+	result := maps.Clone(current)
+	if result == nil {
+		result = make(map[string]string, len(desired))
+	}
+	if managed, ok := result[managedAnnotationsAnnotation]; ok {
+		for _, key := range strings.Split(managed, ",") {
+			delete(result, key)
+		}
+		delete(result, managedAnnotationsAnnotation)
+	}
+	maps.Copy(result, desired)
+	return result
+}
+
+// Services are skipped when disabled or when there are no ports to expose
+func (sa *SimpleApp) serviceEnabled() bool {
+	if sa.Spec.Service != nil && !isEnabled(sa.Spec.Service) {
+		return false
+	}
+	return len(sa.Spec.Ports) > 0
+}
+
+func isEnabled(s *v1alpha1.SimpleAppService) bool {
+	return s.Enabled == nil || *s.Enabled
+}
+
+func (sa *SimpleApp) namedServiceName(name string) string {
+	return sa.Name + "-" + name
+}
+
+func (sa *SimpleApp) buildPodTemplate() (corev1.PodTemplateSpec, error) {
 	// If there are duplicate ContanerPorts, we will remove them silently.
 	// This prevents a warning and an ugly configuration.
 	ports := make([]corev1.ContainerPort, 0, len(sa.Spec.Ports))
@@ -233,41 +448,95 @@ outer:
 	volumeMounts := make([]corev1.VolumeMount, 0, len(sa.Spec.Volumes))
 
 	for _, saVolume := range sa.Spec.Volumes {
-		volume, volumeMount, err := sa.makeVolume(saVolume)
+		// Claim templates only render a mount, the StatefulSet provides the volume
+		if saVolume.VolumeClaimTemplate != nil {
+			if sa.workloadType() != workloadStatefulSet {
+				return corev1.PodTemplateSpec{}, fmt.Errorf("volume for path %v in %v.%v uses volumeClaimTemplate outside a StatefulSet", saVolume.MountPath, sa.Namespace, sa.Name)
+			}
+			volumeMounts = append(volumeMounts, buildVolumeMounts(&saVolume)...)
+			continue
+		}
+		volume, err := sa.makeVolume(saVolume)
 		if err != nil {
-			return appsv1.Deployment{}, err
+			return corev1.PodTemplateSpec{}, err
 		}
 		volumes = append(volumes, volume)
-		volumeMounts = append(volumeMounts, volumeMount)
+		volumeMounts = append(volumeMounts, buildVolumeMounts(&saVolume)...)
+	}
+	mountPaths := make(map[string]struct{}, len(volumeMounts))
+	for _, volumeMount := range volumeMounts {
+		if _, ok := mountPaths[volumeMount.MountPath]; ok {
+			return corev1.PodTemplateSpec{}, fmt.Errorf("duplicate mountPath %v in %v.%v", volumeMount.MountPath, sa.Namespace, sa.Name)
+		}
+		mountPaths[volumeMount.MountPath] = struct{}{}
+	}
+	podSecurityContext, securityContext, err := sa.buildSecurityContexts()
+	if err != nil {
+		return corev1.PodTemplateSpec{}, err
+	}
+	initContainers, sidecars, err := sa.buildExtraContainers(volumeMounts, securityContext)
+	if err != nil {
+		return corev1.PodTemplateSpec{}, err
 	}
 	podSpec := corev1.PodSpec{
-		Containers: []corev1.Container{
+		InitContainers: initContainers,
+		Containers: append([]corev1.Container{
 			corev1.Container{
-				Name:         sa.Metadata.Name,
-				Image:        sa.Spec.Image,
-				Ports:        ports,
-				VolumeMounts: volumeMounts,
-				Env:          sa.Spec.Env,
+				Name:            sa.Name,
+				Image:           sa.Spec.Image,
+				Ports:           ports,
+				VolumeMounts:    volumeMounts,
+				Env:             sa.Spec.Env,
+				SecurityContext: securityContext,
 			},
+		}, sidecars...),
+		Volumes:         volumes,
+		SecurityContext: podSecurityContext,
+	}
+	err = sa.applyScheduling(&podSpec)
+	if err != nil {
+		return corev1.PodTemplateSpec{}, err
+	}
+	err = sa.applyLifecycle(&podSpec)
+	if err != nil {
+		return corev1.PodTemplateSpec{}, err
+	}
+	err = sa.applyPod(&podSpec)
+	if err != nil {
+		return corev1.PodTemplateSpec{}, err
+	}
+	podTemplate := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      sa.podLabels(),
+			Annotations: sa.podAnnotations(),
 		},
-		Volumes: volumes,
+		Spec: podSpec,
+	}
+	return podTemplate, nil
+}
+
+func (sa *SimpleApp) buildDeployment() (appsv1.Deployment, error) {
+	podTemplate, err := sa.buildPodTemplate()
+	if err != nil {
+		return appsv1.Deployment{}, err
+	}
+	strategy, err := sa.buildStrategy()
+	if err != nil {
+		return appsv1.Deployment{}, err
 	}
-	selector := metav1.LabelSelector{}
-	metav1.AddLabelToSelector(&selector, "app", sa.Metadata.Name)
-	metav1.AddLabelToSelector(&selector, managedByLabel, managedByValue)
 	deploymentSpec := appsv1.DeploymentSpec{
-		Template: corev1.PodTemplateSpec{
-			ObjectMeta: metav1.ObjectMeta{
-				Labels: sa.labels(),
-			},
-			Spec: podSpec,
-		},
-		Selector: &selector,
-		Replicas: sa.Spec.Replicas,
+		Template:                podTemplate,
+		Selector:                sa.selector(),
+		Replicas:                sa.replicas(),
+		Paused:                  sa.Spec.Suspend && sa.suspendMode() == suspendModePaused,
+		Strategy:                strategy,
+		MinReadySeconds:         sa.Spec.MinReadySeconds,
+		ProgressDeadlineSeconds: sa.Spec.ProgressDeadlineSeconds,
+		RevisionHistoryLimit:    sa.Spec.RevisionHistoryLimit,
 	}
 	deployment := appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   sa.Metadata.Name,
+			Name:   sa.Name,
 			Labels: sa.labels(),
 		},
 		Spec: deploymentSpec,
@@ -275,9 +544,39 @@ outer:
 	return deployment, nil
 }
 
-func (sa *SimpleApp) makeVolume(saVolume simpleAppVolume) (corev1.Volume, corev1.VolumeMount, error) {
+func volumeName(mountPath string) string {
 	// Use a simplified version of k8s.io/pkg/controller/ ComputeHash
-	volName := fmt.Sprintf("vol-%s", rand.SafeEncodeString(fmt.Sprintf("%x", crc32.ChecksumIEEE([]byte(saVolume.MountPath)))))
+	return fmt.Sprintf("vol-%s", rand.SafeEncodeString(fmt.Sprintf("%x", crc32.ChecksumIEEE([]byte(mountPath)))))
+}
+
+// Mounts of the volume, named after its main mountPath
+func buildVolumeMounts(v *v1alpha1.SimpleAppVolume) []corev1.VolumeMount {
+	volName := volumeName(v.MountPath)
+	volumeMounts := []corev1.VolumeMount{
+		corev1.VolumeMount{
+			Name:             volName,
+			MountPath:        v.MountPath,
+			SubPath:          v.SubPath,
+			SubPathExpr:      v.SubPathExpr,
+			ReadOnly:         v.ReadOnly,
+			MountPropagation: v.MountPropagation,
+		},
+	}
+	for _, mount := range v.Mounts {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:             volName,
+			MountPath:        mount.MountPath,
+			SubPath:          mount.SubPath,
+			SubPathExpr:      mount.SubPathExpr,
+			ReadOnly:         mount.ReadOnly,
+			MountPropagation: mount.MountPropagation,
+		})
+	}
+	return volumeMounts
+}
+
+func (sa *SimpleApp) makeVolume(saVolume v1alpha1.SimpleAppVolume) (corev1.Volume, error) {
+	volName := volumeName(saVolume.MountPath)
 	volume := corev1.Volume{
 		Name: volName,
 	}
@@ -313,21 +612,82 @@ func (sa *SimpleApp) makeVolume(saVolume simpleAppVolume) (corev1.Volume, corev1
 		volume.Secret = &secretVolumeSource
 	} else if saVolume.CSI != nil {
 		volume.CSI = saVolume.CSI
+	} else if saVolume.DownwardAPI != nil {
+		volume.DownwardAPI = saVolume.DownwardAPI
+	} else if saVolume.Projected != nil {
+		volume.Projected = saVolume.Projected
+	} else if saVolume.HostPath != nil {
+		if !allowHostPath {
+			return corev1.Volume{}, fmt.Errorf("hostPath volume for path %v in %v.%v is not allowed by the controller", saVolume.MountPath, sa.Namespace, sa.Name)
+		}
+		volume.HostPath = saVolume.HostPath
+	} else if saVolume.Ephemeral != nil {
+		volume.Ephemeral = &corev1.EphemeralVolumeSource{
+			VolumeClaimTemplate: &corev1.PersistentVolumeClaimTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: sa.labels(),
+				},
+				Spec: buildClaimSpec(saVolume.Ephemeral),
+			},
+		}
+	} else if saVolume.Image != nil {
+		volume.Image = saVolume.Image
 	} else {
-		return corev1.Volume{}, corev1.VolumeMount{}, fmt.Errorf("volume for path %v in %v.%v does not have type", saVolume.MountPath, sa.Metadata.Namespace, sa.Metadata.Name)
-	}
-	volumeMount := corev1.VolumeMount{
-		Name:      volName,
-		MountPath: saVolume.MountPath,
+		return corev1.Volume{}, fmt.Errorf("volume for path %v in %v.%v does not have type", saVolume.MountPath, sa.Namespace, sa.Name)
 	}
-	return volume, volumeMount, nil
+	return volume, nil
 }
 
 func (sa SimpleApp) delete(clientset *kubernetes.Clientset) error {
+	switch sa.workloadType() {
+	case workloadDeployment:
+		err := deleteDeployment(clientset, sa.Namespace, sa.Name)
+		if err != nil {
+			return err
+		}
+	case workloadStatefulSet:
+		err := deleteStatefulSet(clientset, sa.Namespace, sa.Name)
+		if err != nil {
+			return err
+		}
+	case workloadDaemonSet:
+		err := deleteDaemonSet(clientset, sa.Namespace, sa.Name)
+		if err != nil {
+			return err
+		}
+	case workloadJob:
+		err := deleteJob(clientset, sa.Namespace, sa.Name)
+		if err != nil {
+			return err
+		}
+	case workloadCronJob:
+		err := deleteCronJob(clientset, sa.Namespace, sa.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range sa.serviceNames() {
+		err := deleteService(clientset, sa.Namespace, name, sa.Name)
+		if err != nil {
+			return err
+		}
+	}
+	for _, name := range sa.claimNames() {
+		err := deletePersistentVolumeClaim(clientset, sa.Namespace, name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteDeployment(clientset *kubernetes.Clientset, namespace, name string) error {
 	// Get current Deployment
-	oldDeployment, err := clientset.AppsV1().Deployments(sa.Metadata.Namespace).Get(context.TODO(), sa.Metadata.Name, metav1.GetOptions{})
+	oldDeployment, err := clientset.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
 	if errors.IsNotFound(err) {
-		log.Printf("Deployment %v.%v already deleted", sa.Metadata.Namespace, sa.Metadata.Name)
+		log.Printf("Deployment %v.%v already deleted", namespace, name)
+		return nil
 	} else if err != nil {
 		return err
 	}
@@ -339,19 +699,28 @@ func (sa SimpleApp) delete(clientset *kubernetes.Clientset) error {
 	if err != nil {
 		return err
 	}
-	log.Printf("Deleted Deployment %v.%v", sa.Metadata.Namespace, sa.Metadata.Name)
+	log.Printf("Deleted Deployment %v.%v", namespace, name)
+	return nil
+}
 
+// Deletes the Service if it belongs to the given SimpleApp
+func deleteService(clientset kubernetes.Interface, namespace, name, owner string) error {
 	// Get current Service
-	oldService, err := clientset.CoreV1().Services(sa.Metadata.Namespace).Get(context.TODO(), sa.Metadata.Name, metav1.GetOptions{})
+	oldService, err := clientset.CoreV1().Services(namespace).Get(context.TODO(), name, metav1.GetOptions{})
 	if errors.IsNotFound(err) {
-		log.Printf("Service %v.%v already deleted", sa.Metadata.Namespace, sa.Metadata.Name)
+		log.Printf("Service %v.%v already deleted", namespace, name)
+		return nil
 	} else if err != nil {
 		return err
 	}
-	managedBy, ok = oldService.Labels[managedByLabel]
+	managedBy, ok := oldService.Labels[managedByLabel]
 	if !ok || managedBy != managedByValue {
 		return fmt.Errorf("found Service %v.%v not managed by us", oldService.ObjectMeta.Namespace, oldService.ObjectMeta.Name)
 	}
+	if instanceOf(oldService.Labels) != owner {
+		log.Printf("Service %v.%v belongs to another SimpleApp, not deleting it", oldService.ObjectMeta.Namespace, oldService.ObjectMeta.Name)
+		return nil
+	}
 	err = clientset.CoreV1().Services(oldService.ObjectMeta.Namespace).Delete(context.TODO(), oldService.ObjectMeta.Name, metav1.DeleteOptions{})
 	if err != nil {
 		return err
@@ -359,65 +728,3 @@ func (sa SimpleApp) delete(clientset *kubernetes.Clientset) error {
 	log.Printf("Deleted Service %v.%v", oldService.ObjectMeta.Namespace, oldService.ObjectMeta.Name)
 	return nil
 }
-
-func (sa *SimpleApp) fixPorts() bool {
-	newPorts := make([]simpleAppPort, 0)
-outer:
-	for _, port := range sa.Spec.Ports {
-		for _, stored := range newPorts {
-			if port.HostPort == stored.HostPort && port.Protocol == stored.Protocol {
-				log.Printf("Found duplicate port %v/%v in SimpleApp %v.%v, removing it", port.HostPort, port.Protocol, sa.Metadata.Namespace, sa.Metadata.Name)
-				continue outer
-			}
-			if port.Name != "" && fmt.Sprintf("%.13v", port.Name) == fmt.Sprintf("%.13v", stored.Name) {
-				log.Printf("Found duplicate port name %v in SimpleApp %v.%v, removing it", port.Name, sa.Metadata.Namespace, sa.Metadata.Name)
-				continue outer
-			}
-		}
-		newPorts = append(newPorts, port)
-	}
-
-	if len(sa.Spec.Ports) == len(newPorts) {
-		return false
-	}
-
-	log.Printf("Removed %v duplicate port(s) from SimpleApp %v.%v", len(sa.Spec.Ports)-len(newPorts), sa.Metadata.Namespace, sa.Metadata.Name)
-	sa.Spec.Ports = newPorts
-
-	return true
-}
-
-func (sa *SimpleApp) fixVolumes() bool {
-	newVolumes := make([]simpleAppVolume, 0)
-outer:
-	for _, volume := range sa.Spec.Volumes {
-		for _, stored := range newVolumes {
-			if volume.MountPath == stored.MountPath {
-				continue outer
-			}
-		}
-		newVolumes = append(newVolumes, volume)
-	}
-
-	if len(sa.Spec.Volumes) == len(newVolumes) {
-		return false
-	}
-
-	log.Printf("Removing %v duplicate volume(s) from SimpleApp %v.%v", len(sa.Spec.Volumes)-len(newVolumes), sa.Metadata.Namespace, sa.Metadata.Name)
-	sa.Spec.Volumes = newVolumes
-
-	return true
-}
-
-func (sa *SimpleApp) updateApp(clientset *kubernetes.Clientset) error {
-	payload, err := json.Marshal(sa)
-	if err != nil {
-		return err
-	}
-
-	result := clientset.RESTClient().Put().AbsPath("/apis/" + resourcePath).Namespace(sa.Metadata.Namespace).Resource(plural).Name(sa.Metadata.Name).Body(payload).Do(context.TODO())
-	if result.Error() != nil {
-		return result.Error()
-	}
-	return nil
-}
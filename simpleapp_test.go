@@ -0,0 +1,99 @@
+package main
+
+import (
+	"maps"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMergeServiceAnnotations(t *testing.T) {
+	negStatus := "cloud.google.com/neg-status"
+	tests := []struct {
+		name    string
+		current map[string]string
+		spec    map[string]string
+		want    map[string]string
+	}{
+		{
+			name:    "new annotations",
+			current: nil,
+			spec:    map[string]string{"a": "1"},
+			want:    map[string]string{"a": "1", managedAnnotationsAnnotation: "a"},
+		},
+		{
+			name:    "foreign annotations kept",
+			current: map[string]string{"a": "1", negStatus: "{}", managedAnnotationsAnnotation: "a"},
+			spec:    map[string]string{"a": "2"},
+			want:    map[string]string{"a": "2", negStatus: "{}", managedAnnotationsAnnotation: "a"},
+		},
+		{
+			name:    "removed annotations deleted",
+			current: map[string]string{"a": "1", "b": "2", negStatus: "{}", managedAnnotationsAnnotation: "a,b"},
+			spec:    map[string]string{"b": "2"},
+			want:    map[string]string{"b": "2", negStatus: "{}", managedAnnotationsAnnotation: "b"},
+		},
+		{
+			name:    "all annotations removed",
+			current: map[string]string{"a": "1", negStatus: "{}", managedAnnotationsAnnotation: "a"},
+			spec:    nil,
+			want:    map[string]string{negStatus: "{}"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeServiceAnnotations(tt.current, serviceAnnotations(tt.spec))
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("mergeServiceAnnotations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcileServiceOwner(t *testing.T) {
+	sa := testSimpleApp(t, `{"image": "nginx:1.29", "ports": [{"name": "http", "containerPort": 80, "hostPort": 80}], "services": [{"name": "web"}]}`)
+	services, err := sa.buildServices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	named := services[1]
+	if named.Name != "test-web" {
+		t.Fatalf("named Service is %v, want test-web", named.Name)
+	}
+
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		wantErr bool
+	}{
+		{"same SimpleApp", named.Labels, false},
+		{"legacy labels", map[string]string{legacyAppLabel: "test", managedByLabel: managedByValue}, false},
+		// The main Service of a SimpleApp named test-web
+		{"other SimpleApp", map[string]string{instanceLabel: "test-web", managedByLabel: managedByValue}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := *named.DeepCopy()
+			current.Labels = tt.labels
+			clientset := fake.NewClientset(&current)
+			err := reconcileService(clientset, "default", named)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("reconcileService() returned %v, want error %v", err, tt.wantErr)
+			}
+
+			err = deleteService(clientset, "default", named.Name, sa.Name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			deleted := false
+			for _, action := range clientset.Actions() {
+				if action.GetVerb() == "delete" {
+					deleted = true
+				}
+			}
+			if deleted == tt.wantErr {
+				t.Errorf("Service deleted = %v, want %v", deleted, !tt.wantErr)
+			}
+		})
+	}
+}
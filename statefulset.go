@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/pecio/simpleapp/api/v1alpha1"
+	"github.com/pecio/simpleapp/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func buildClaimSpec(t *v1alpha1.SimpleAppVolumeClaimTemplate) corev1.PersistentVolumeClaimSpec {
+	accessModes := t.AccessModes
+	if len(accessModes) == 0 {
+		accessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	}
+	return corev1.PersistentVolumeClaimSpec{
+		StorageClassName: t.StorageClassName,
+		AccessModes:      accessModes,
+		Resources: corev1.VolumeResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceStorage: t.Size,
+			},
+		},
+	}
+}
+
+func (sa *SimpleApp) headlessServiceName() string {
+	return sa.Name + "-headless"
+}
+
+// The governing Service of the StatefulSet, giving each pod a stable DNS name
+func (sa *SimpleApp) buildHeadlessService() (corev1.Service, error) {
+	servicePorts := buildServicePorts(sa.Spec.Ports)
+	for i := range servicePorts {
+		servicePorts[i].NodePort = 0
+	}
+	service := corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: sa.Namespace,
+			Name:      sa.headlessServiceName(),
+			Labels:    sa.labels(),
+		},
+		Spec: corev1.ServiceSpec{
+			Selector:  sa.selectorLabels(),
+			Ports:     servicePorts,
+			Type:      corev1.ServiceTypeClusterIP,
+			ClusterIP: corev1.ClusterIPNone,
+		},
+	}
+	return service, nil
+}
+
+func (sa *SimpleApp) buildStatefulSet() (appsv1.StatefulSet, error) {
+	podTemplate, err := sa.buildPodTemplate()
+	if err != nil {
+		return appsv1.StatefulSet{}, err
+	}
+
+	volumeClaimTemplates := make([]corev1.PersistentVolumeClaim, 0)
+	for _, saVolume := range sa.Spec.Volumes {
+		if saVolume.VolumeClaimTemplate == nil {
+			continue
+		}
+		volumeClaimTemplate := corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: volumeName(saVolume.MountPath),
+				// Only the labels that never change, as the templates
+				// cannot be updated
+				Labels: sa.selectorLabels(),
+			},
+			Spec: buildClaimSpec(saVolume.VolumeClaimTemplate),
+		}
+		volumeClaimTemplates = append(volumeClaimTemplates, volumeClaimTemplate)
+	}
+	if len(volumeClaimTemplates) == 0 {
+		volumeClaimTemplates = nil
+	}
+
+	statefulSet := appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   sa.Name,
+			Labels: sa.labels(),
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Template:             podTemplate,
+			Selector:             sa.selector(),
+			Replicas:             sa.replicas(),
+			ServiceName:          sa.headlessServiceName(),
+			VolumeClaimTemplates: volumeClaimTemplates,
+			MinReadySeconds:      sa.Spec.MinReadySeconds,
+			RevisionHistoryLimit: sa.Spec.RevisionHistoryLimit,
+		},
+	}
+	return statefulSet, nil
+}
+
+func reconcileStatefulSet(clientset kubernetes.Interface, namespace string, newStatefulSet appsv1.StatefulSet) error {
+	// Check if StatefulSet exists
+	oldStatefulSet, err := clientset.AppsV1().StatefulSets(namespace).Get(context.TODO(), newStatefulSet.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = clientset.AppsV1().StatefulSets(namespace).Create(context.TODO(), &newStatefulSet, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		log.Printf("Created StatefulSet %v.%v", namespace, newStatefulSet.Name)
+	} else if err != nil {
+		return err
+	} else {
+		// Check if StatefulSet is ours
+		managedBy, ok := oldStatefulSet.ObjectMeta.Labels[managedByLabel]
+		if !ok || managedBy != managedByValue {
+			return fmt.Errorf("found StatefulSet %v.%v not managed by us", oldStatefulSet.ObjectMeta.Namespace, oldStatefulSet.ObjectMeta.Name)
+		}
+
+		if oldStatefulSet.DeletionTimestamp != nil {
+			return nil
+		}
+
+		// The pods mount a volume for each claim template, so adding or
+		// removing one needs a new StatefulSet. The old one is deleted
+		// leaving its pods and claims behind, for the next pass to create it
+		// again and adopt them.
+		if !slices.Equal(claimTemplateNames(newStatefulSet.Spec.VolumeClaimTemplates), claimTemplateNames(oldStatefulSet.Spec.VolumeClaimTemplates)) {
+			propagationPolicy := metav1.DeletePropagationOrphan
+			err = clientset.AppsV1().StatefulSets(namespace).Delete(context.TODO(), oldStatefulSet.Name, metav1.DeleteOptions{PropagationPolicy: &propagationPolicy})
+			if err != nil {
+				return err
+			}
+			log.Printf("Deleted StatefulSet %v.%v to add or remove volumeClaimTemplates, keeping its pods", namespace, oldStatefulSet.Name)
+			return nil
+		}
+
+		// Otherwise volumeClaimTemplates are immutable, including their
+		// labels, so always keep the existing ones
+		if !utils.VolumeClaimTemplatesEqual(newStatefulSet.Spec.VolumeClaimTemplates, oldStatefulSet.Spec.VolumeClaimTemplates) {
+			log.Printf("volumeClaimTemplates of StatefulSet %v.%v cannot be changed, keeping existing ones", oldStatefulSet.ObjectMeta.Namespace, oldStatefulSet.ObjectMeta.Name)
+		}
+		newStatefulSet.Spec.VolumeClaimTemplates = oldStatefulSet.Spec.VolumeClaimTemplates
+
+		if !utils.StatefulSetEqual(newStatefulSet, *oldStatefulSet) {
+			_, err = clientset.AppsV1().StatefulSets(oldStatefulSet.ObjectMeta.Namespace).Update(context.TODO(), &newStatefulSet, metav1.UpdateOptions{})
+			if err != nil {
+				return err
+			}
+			log.Printf("StatefulSet %v.%v updated", oldStatefulSet.ObjectMeta.Namespace, oldStatefulSet.ObjectMeta.Name)
+		}
+	}
+	return nil
+}
+
+func claimTemplateNames(templates []corev1.PersistentVolumeClaim) []string {
+	names := make([]string, 0, len(templates))
+	for _, template := range templates {
+		names = append(names, template.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func deleteStatefulSet(clientset *kubernetes.Clientset, namespace, name string) error {
+	// Get current StatefulSet
+	oldStatefulSet, err := clientset.AppsV1().StatefulSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		log.Printf("StatefulSet %v.%v already deleted", namespace, name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	managedBy, ok := oldStatefulSet.Labels[managedByLabel]
+	if !ok || managedBy != managedByValue {
+		return fmt.Errorf("found StatefulSet %v.%v not managed by us", oldStatefulSet.ObjectMeta.Namespace, oldStatefulSet.ObjectMeta.Name)
+	}
+	// PersistentVolumeClaims created from the templates are kept
+	err = clientset.AppsV1().StatefulSets(oldStatefulSet.ObjectMeta.Namespace).Delete(context.TODO(), oldStatefulSet.ObjectMeta.Name, metav1.DeleteOptions{})
+	if err != nil {
+		return err
+	}
+	log.Printf("Deleted StatefulSet %v.%v", namespace, name)
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestReconcileStatefulSetClaimTemplates(t *testing.T) {
+	build := func(volumes string) appsv1.StatefulSet {
+		t.Helper()
+		sa := testSimpleApp(t, `{"image": "postgres:17", "workloadType": "StatefulSet", "volumes": `+volumes+`}`)
+		statefulSet, err := sa.buildStatefulSet()
+		if err != nil {
+			t.Fatal(err)
+		}
+		statefulSet.Namespace = sa.Namespace
+		return statefulSet
+	}
+	data := `{"mountPath": "/data", "volumeClaimTemplate": {"size": "1Gi"}}`
+	wal := `{"mountPath": "/wal", "volumeClaimTemplate": {"size": "1Gi"}}`
+	largerData := `{"mountPath": "/data", "volumeClaimTemplate": {"size": "2Gi"}}`
+
+	tests := []struct {
+		name       string
+		volumes    string
+		wantDelete bool
+	}{
+		{"unchanged", `[` + data + `]`, false},
+		{"resized", `[` + largerData + `]`, false},
+		{"added", `[` + data + `, ` + wal + `]`, true},
+		{"removed", `[]`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := build(`[` + data + `]`)
+			clientset := fake.NewClientset(&current)
+			err := reconcileStatefulSet(clientset, "default", build(tt.volumes))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			deleted := false
+			for _, action := range clientset.Actions() {
+				switch action := action.(type) {
+				case k8stesting.DeleteAction:
+					deleted = true
+					policy := action.GetDeleteOptions().PropagationPolicy
+					if policy == nil || *policy != metav1.DeletePropagationOrphan {
+						t.Errorf("StatefulSet deleted with propagationPolicy %v, want Orphan", policy)
+					}
+				case k8stesting.UpdateAction:
+					statefulSet := action.GetObject().(*appsv1.StatefulSet)
+					if !claimTemplatesMatch(statefulSet, &current) {
+						t.Error("volumeClaimTemplates of the existing StatefulSet were changed")
+					}
+				}
+			}
+			if deleted != tt.wantDelete {
+				t.Errorf("StatefulSet deleted = %v, want %v", deleted, tt.wantDelete)
+			}
+			if _, err := clientset.AppsV1().StatefulSets("default").Get(context.TODO(), "test", metav1.GetOptions{}); (err != nil) != tt.wantDelete {
+				t.Errorf("getting the StatefulSet returned %v", err)
+			}
+		})
+	}
+}
+
+func claimTemplatesMatch(s1, s2 *appsv1.StatefulSet) bool {
+	if len(s1.Spec.VolumeClaimTemplates) != len(s2.Spec.VolumeClaimTemplates) {
+		return false
+	}
+	for i := range s1.Spec.VolumeClaimTemplates {
+		if !s1.Spec.VolumeClaimTemplates[i].Spec.Resources.Requests.Storage().Equal(*s2.Spec.VolumeClaimTemplates[i].Spec.Resources.Requests.Storage()) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pecio/simpleapp/api/v1alpha1"
+	"github.com/pecio/simpleapp/pkg/generated/clientset/versioned"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	runResultRunning   = "Running"
+	runResultSucceeded = "Succeeded"
+	runResultFailed    = "Failed"
+	runResultUnknown   = "Unknown"
+
+	conditionReady = "Ready"
+
+	readyReasonAvailable   = "Available"
+	readyReasonProgressing = "Progressing"
+	readyReasonScheduled   = "Scheduled"
+	readyReasonSuspended   = "Suspended"
+)
+
+func (sa *SimpleApp) buildStatus(clientset *kubernetes.Clientset) (v1alpha1.SimpleAppStatus, error) {
+	// Keep the transition times of the conditions that did not change
+	status := v1alpha1.SimpleAppStatus{Conditions: slices.Clone(sa.Status.Conditions)}
+	lastRun, err := sa.lastRun(clientset)
+	if err != nil {
+		return v1alpha1.SimpleAppStatus{}, err
+	}
+	status.LastRun = lastRun
+	ready, err := sa.workloadStatus(clientset, &status)
+	if err != nil {
+		return v1alpha1.SimpleAppStatus{}, err
+	}
+	if sa.stopped() {
+		ready = metav1.Condition{Status: metav1.ConditionFalse, Reason: readyReasonSuspended, Message: "the SimpleApp is suspended"}
+	}
+	ready.Type = conditionReady
+	ready.ObservedGeneration = sa.Generation
+	meta.SetStatusCondition(&status.Conditions, ready)
+	meta.SetStatusCondition(&status.Conditions, sa.suspendedCondition())
+	err = sa.serviceStatus(clientset, &status)
+	if err != nil {
+		return v1alpha1.SimpleAppStatus{}, err
+	}
+	return status, nil
+}
+
+// Fills in the image, replicas and last restart of the workload and returns
+// its Ready condition
+func (sa *SimpleApp) workloadStatus(clientset *kubernetes.Clientset, status *v1alpha1.SimpleAppStatus) (metav1.Condition, error) {
+	var template corev1.PodTemplateSpec
+	var err error
+	// Pods running the current spec, zero until the workload controller has
+	// seen it
+	var updatedReplicas int32
+	var selector *metav1.LabelSelector
+	switch sa.workloadType() {
+	case workloadDeployment:
+		var deployment *appsv1.Deployment
+		deployment, err = clientset.AppsV1().Deployments(sa.Namespace).Get(context.TODO(), sa.Name, metav1.GetOptions{})
+		if err == nil {
+			template = deployment.Spec.Template
+			selector = deployment.Spec.Selector
+			status.Replicas = deployment.Status.Replicas
+			if deployment.Spec.Replicas != nil {
+				status.DesiredReplicas = *deployment.Spec.Replicas
+			}
+			status.ReadyReplicas = deployment.Status.ReadyReplicas
+			if deployment.Status.ObservedGeneration >= deployment.Generation {
+				updatedReplicas = deployment.Status.UpdatedReplicas
+			}
+		}
+	case workloadStatefulSet:
+		var statefulSet *appsv1.StatefulSet
+		statefulSet, err = clientset.AppsV1().StatefulSets(sa.Namespace).Get(context.TODO(), sa.Name, metav1.GetOptions{})
+		if err == nil {
+			template = statefulSet.Spec.Template
+			selector = statefulSet.Spec.Selector
+			status.Replicas = statefulSet.Status.Replicas
+			if statefulSet.Spec.Replicas != nil {
+				status.DesiredReplicas = *statefulSet.Spec.Replicas
+			}
+			status.ReadyReplicas = statefulSet.Status.ReadyReplicas
+			if statefulSet.Status.ObservedGeneration >= statefulSet.Generation {
+				updatedReplicas = statefulSet.Status.UpdatedReplicas
+			}
+		}
+	case workloadDaemonSet:
+		var daemonSet *appsv1.DaemonSet
+		daemonSet, err = clientset.AppsV1().DaemonSets(sa.Namespace).Get(context.TODO(), sa.Name, metav1.GetOptions{})
+		if err == nil {
+			template = daemonSet.Spec.Template
+			selector = daemonSet.Spec.Selector
+			status.Replicas = daemonSet.Status.CurrentNumberScheduled
+			status.DesiredReplicas = daemonSet.Status.DesiredNumberScheduled
+			status.ReadyReplicas = daemonSet.Status.NumberReady
+			if daemonSet.Status.ObservedGeneration >= daemonSet.Generation {
+				updatedReplicas = daemonSet.Status.UpdatedNumberScheduled
+			}
+		}
+	case workloadJob:
+		var job *batchv1.Job
+		job, err = clientset.BatchV1().Jobs(sa.Namespace).Get(context.TODO(), sa.Name, metav1.GetOptions{})
+		if err == nil {
+			status.Image = applicationImage(sa.Name, job.Spec.Template.Spec)
+			status.LastRestartedAt = lastRestartedAt(job.Spec.Template.ObjectMeta)
+			return runCondition(status.LastRun), nil
+		}
+	case workloadCronJob:
+		var cronJob *batchv1.CronJob
+		cronJob, err = clientset.BatchV1().CronJobs(sa.Namespace).Get(context.TODO(), sa.Name, metav1.GetOptions{})
+		if err == nil {
+			status.Image = applicationImage(sa.Name, cronJob.Spec.JobTemplate.Spec.Template.Spec)
+			status.LastRestartedAt = lastRestartedAt(cronJob.Spec.JobTemplate.Spec.Template.ObjectMeta)
+			return runCondition(status.LastRun), nil
+		}
+	}
+	if errors.IsNotFound(err) {
+		// A Job being replaced, for instance
+		return metav1.Condition{
+			Status:  metav1.ConditionFalse,
+			Reason:  readyReasonProgressing,
+			Message: fmt.Sprintf("%v %v does not exist", sa.workloadType(), sa.Name),
+		}, nil
+	} else if err != nil {
+		return metav1.Condition{}, err
+	}
+
+	status.Image = applicationImage(sa.Name, template.Spec)
+	status.LastRestartedAt = lastRestartedAt(template.ObjectMeta)
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return metav1.Condition{}, err
+	}
+	status.Selector = labelSelector.String()
+	message := fmt.Sprintf("%d of %d pods ready, %d updated", status.ReadyReplicas, status.DesiredReplicas, updatedReplicas)
+	if updatedReplicas < status.DesiredReplicas || status.ReadyReplicas < status.DesiredReplicas {
+		return metav1.Condition{Status: metav1.ConditionFalse, Reason: readyReasonProgressing, Message: message}, nil
+	}
+	return metav1.Condition{Status: metav1.ConditionTrue, Reason: readyReasonAvailable, Message: message}, nil
+}
+
+// Job and CronJob workloads are ready unless their last run failed, or it is
+// not known how it went
+func runCondition(lastRun *v1alpha1.SimpleAppRunStatus) metav1.Condition {
+	if lastRun == nil {
+		return metav1.Condition{Status: metav1.ConditionTrue, Reason: readyReasonScheduled, Message: "no Job has run yet"}
+	}
+	message := fmt.Sprintf("Job %v %v", lastRun.JobName, strings.ToLower(lastRun.Result))
+	if lastRun.Message != "" {
+		message += ": " + lastRun.Message
+	}
+	if lastRun.Result == runResultFailed {
+		return metav1.Condition{Status: metav1.ConditionFalse, Reason: lastRun.Result, Message: message}
+	} else if lastRun.Result == runResultUnknown {
+		return metav1.Condition{Status: metav1.ConditionUnknown, Reason: lastRun.Result, Message: message}
+	}
+	return metav1.Condition{Status: metav1.ConditionTrue, Reason: lastRun.Result, Message: message}
+}
+
+// Image of the application container, named after the SimpleApp
+func applicationImage(name string, podSpec corev1.PodSpec) string {
+	for _, container := range podSpec.Containers {
+		if container.Name == name {
+			return container.Image
+		}
+	}
+	return ""
+}
+
+// Fills in the type and load balancer address of the Service named after the
+// SimpleApp
+func (sa *SimpleApp) serviceStatus(clientset *kubernetes.Clientset, status *v1alpha1.SimpleAppStatus) error {
+	if !sa.serviceEnabled() {
+		return nil
+	}
+	service, err := clientset.CoreV1().Services(sa.Namespace).Get(context.TODO(), sa.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	status.ServiceType = service.Spec.Type
+	addresses := make([]string, 0, len(service.Status.LoadBalancer.Ingress))
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.Hostname != "" {
+			addresses = append(addresses, ingress.Hostname)
+		} else if ingress.IP != "" {
+			addresses = append(addresses, ingress.IP)
+		}
+	}
+	status.Address = strings.Join(addresses, ",")
+	return nil
+}
+
+// Writes the status subresource if it changed
+func (sa *SimpleApp) updateStatus(clientset *kubernetes.Clientset, appClientset versioned.Interface) error {
+	status, err := sa.buildStatus(clientset)
+	if err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(status, sa.Status) {
+		return nil
+	}
+	sa.Status = status
+
+	_, err = appClientset.AppsV1alpha1().SimpleApps(sa.Namespace).UpdateStatus(context.TODO(), &sa.SimpleApp, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+func (sa *SimpleApp) buildStrategy() (appsv1.DeploymentStrategy, error) {
+	saStrategy := sa.Spec.Strategy
+	if saStrategy == nil {
+		return appsv1.DeploymentStrategy{}, nil
+	}
+	switch saStrategy.Type {
+	case string(appsv1.RecreateDeploymentStrategyType):
+		if saStrategy.MaxSurge != nil || saStrategy.MaxUnavailable != nil {
+			return appsv1.DeploymentStrategy{}, fmt.Errorf("maxSurge and maxUnavailable are not allowed with Recreate strategy in %v.%v", sa.Namespace, sa.Name)
+		}
+		return appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}, nil
+	case "", string(appsv1.RollingUpdateDeploymentStrategyType):
+		strategy := appsv1.DeploymentStrategy{Type: appsv1.RollingUpdateDeploymentStrategyType}
+		if saStrategy.MaxSurge != nil || saStrategy.MaxUnavailable != nil {
+			strategy.RollingUpdate = &appsv1.RollingUpdateDeployment{
+				MaxSurge:       saStrategy.MaxSurge,
+				MaxUnavailable: saStrategy.MaxUnavailable,
+			}
+		}
+		return strategy, nil
+	default:
+		return appsv1.DeploymentStrategy{}, fmt.Errorf("unknown strategy type %v in %v.%v", saStrategy.Type, sa.Namespace, sa.Name)
+	}
+}
+
+func (sa *SimpleApp) buildDaemonSetStrategy() (appsv1.DaemonSetUpdateStrategy, error) {
+	saStrategy := sa.Spec.Strategy
+	if saStrategy == nil {
+		return appsv1.DaemonSetUpdateStrategy{}, nil
+	}
+	switch saStrategy.Type {
+	case string(appsv1.OnDeleteDaemonSetStrategyType):
+		if saStrategy.MaxSurge != nil || saStrategy.MaxUnavailable != nil {
+			return appsv1.DaemonSetUpdateStrategy{}, fmt.Errorf("maxSurge and maxUnavailable are not allowed with OnDelete strategy in %v.%v", sa.Namespace, sa.Name)
+		}
+		return appsv1.DaemonSetUpdateStrategy{Type: appsv1.OnDeleteDaemonSetStrategyType}, nil
+	case "", string(appsv1.RollingUpdateDaemonSetStrategyType):
+		strategy := appsv1.DaemonSetUpdateStrategy{Type: appsv1.RollingUpdateDaemonSetStrategyType}
+		if saStrategy.MaxSurge != nil || saStrategy.MaxUnavailable != nil {
+			strategy.RollingUpdate = &appsv1.RollingUpdateDaemonSet{
+				MaxSurge:       saStrategy.MaxSurge,
+				MaxUnavailable: saStrategy.MaxUnavailable,
+			}
+		}
+		return strategy, nil
+	default:
+		return appsv1.DaemonSetUpdateStrategy{}, fmt.Errorf("unknown strategy type %v for DaemonSet in %v.%v", saStrategy.Type, sa.Namespace, sa.Name)
+	}
+}
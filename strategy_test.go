@@ -0,0 +1,89 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestDeploymentStrategy(t *testing.T) {
+	one, tenPercent := intstr.FromInt32(1), intstr.FromString("10%")
+	tests := []struct {
+		name     string
+		strategy string
+		want     appsv1.DeploymentStrategy
+		wantErr  string
+	}{
+		{
+			name:     "unset",
+			strategy: `null`,
+			want:     appsv1.DeploymentStrategy{},
+		},
+		{
+			name:     "rolling update by default",
+			strategy: `{"maxSurge": 1, "maxUnavailable": "10%"}`,
+			want: appsv1.DeploymentStrategy{
+				Type:          appsv1.RollingUpdateDeploymentStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateDeployment{MaxSurge: &one, MaxUnavailable: &tenPercent},
+			},
+		},
+		{
+			name:     "rolling update without limits",
+			strategy: `{"type": "RollingUpdate"}`,
+			want:     appsv1.DeploymentStrategy{Type: appsv1.RollingUpdateDeploymentStrategyType},
+		},
+		{
+			name:     "recreate",
+			strategy: `{"type": "Recreate"}`,
+			want:     appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
+		},
+		{
+			name:     "recreate with limits",
+			strategy: `{"type": "Recreate", "maxUnavailable": 1}`,
+			wantErr:  "not allowed with Recreate strategy",
+		},
+		{
+			name:     "onDelete",
+			strategy: `{"type": "OnDelete"}`,
+			wantErr:  "unknown strategy type OnDelete",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sa := testSimpleApp(t, `{"image": "nginx:1.27", "strategy": `+tt.strategy+`}`)
+			deployment, err := sa.buildDeployment()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("buildDeployment() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(deployment.Spec.Strategy, tt.want) {
+				t.Errorf("strategy = %+v, want %+v", deployment.Spec.Strategy, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeploymentRolloutSettings(t *testing.T) {
+	sa := testSimpleApp(t, `{"image": "nginx:1.27", "minReadySeconds": 10, "progressDeadlineSeconds": 120, "revisionHistoryLimit": 3}`)
+	deployment, err := sa.buildDeployment()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deployment.Spec.MinReadySeconds != 10 {
+		t.Errorf("minReadySeconds = %v, want 10", deployment.Spec.MinReadySeconds)
+	}
+	if deployment.Spec.ProgressDeadlineSeconds == nil || *deployment.Spec.ProgressDeadlineSeconds != 120 {
+		t.Errorf("progressDeadlineSeconds = %v, want 120", deployment.Spec.ProgressDeadlineSeconds)
+	}
+	if deployment.Spec.RevisionHistoryLimit == nil || *deployment.Spec.RevisionHistoryLimit != 3 {
+		t.Errorf("revisionHistoryLimit = %v, want 3", deployment.Spec.RevisionHistoryLimit)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	suspendModeScaleToZero = "ScaleToZero"
+	suspendModePaused      = "Paused"
+
+	conditionSuspended = "Suspended"
+
+	suspendedReasonScaledToZero = "ScaledToZero"
+	suspendedReasonPaused       = "Paused"
+	suspendedReasonSuspended    = "Suspended"
+	suspendedReasonRunning      = "Running"
+)
+
+func (sa *SimpleApp) suspendMode() string {
+	if sa.Spec.SuspendMode == "" {
+		return suspendModeScaleToZero
+	}
+	return sa.Spec.SuspendMode
+}
+
+// Whether the pods of the SimpleApp are stopped. A paused Deployment keeps
+// them running.
+func (sa *SimpleApp) stopped() bool {
+	return sa.Spec.Suspend && sa.suspendMode() != suspendModePaused
+}
+
+// Replicas of the Deployment or StatefulSet. spec.replicas is left alone while
+// suspended, so that it is restored on resume.
+func (sa *SimpleApp) replicas() *int32 {
+	if sa.stopped() {
+		replicas := int32(0)
+		return &replicas
+	}
+	return sa.Spec.Replicas
+}
+
+func (sa *SimpleApp) checkSuspend() []string {
+	errs := make([]string, 0)
+	if !sa.Spec.Suspend {
+		return errs
+	}
+	if sa.workloadType() == workloadDaemonSet {
+		errs = append(errs, fmt.Sprintf("suspend: not supported with workloadType %v", workloadDaemonSet))
+	}
+	if sa.suspendMode() == suspendModePaused && sa.workloadType() != workloadDeployment {
+		errs = append(errs, fmt.Sprintf("suspendMode: %v is only supported with workloadType %v", suspendModePaused, workloadDeployment))
+	}
+	return errs
+}
+
+func (sa *SimpleApp) suspendedCondition() metav1.Condition {
+	condition := metav1.Condition{
+		Type:               conditionSuspended,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: sa.Generation,
+	}
+	switch {
+	case !sa.Spec.Suspend:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = suspendedReasonRunning
+		condition.Message = "not suspended"
+	case sa.workloadType() == workloadJob || sa.workloadType() == workloadCronJob:
+		condition.Reason = suspendedReasonSuspended
+		condition.Message = fmt.Sprintf("%v suspended", sa.workloadType())
+	case sa.suspendMode() == suspendModePaused:
+		condition.Reason = suspendedReasonPaused
+		condition.Message = "rollouts paused, pods keep running"
+	default:
+		condition.Reason = suspendedReasonScaledToZero
+		condition.Message = fmt.Sprintf("scaled to zero, %d replicas on resume", defaultReplicas(sa.Spec.Replicas))
+	}
+	return condition
+}
+
+func defaultReplicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
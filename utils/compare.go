@@ -2,50 +2,221 @@ package utils
 
 import (
 	"log"
+	"maps"
+	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// Set on pod templates by kubectl rollout restart and spec.restartedAt
+const restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
 func DeploymentEqual(d1, d2 appsv1.Deployment) bool {
-	// The easy part - Replicas
-	if *d1.Spec.Replicas != *d2.Spec.Replicas {
+	if !labelsEqual(d1.ObjectMeta.Labels, d2.ObjectMeta.Labels) {
+		return false
+	}
+	// The easy part - Replicas, defaults to 1
+	if defaultInt32(d1.Spec.Replicas, 1) != defaultInt32(d2.Spec.Replicas, 1) {
+		return false
+	}
+	if d1.Spec.Paused != d2.Spec.Paused {
+		return false
+	}
+
+	// Rollout configuration
+	if !strategyEqual(d1.Spec.Strategy, d2.Spec.Strategy) {
+		return false
+	}
+	if d1.Spec.MinReadySeconds != d2.Spec.MinReadySeconds {
+		return false
+	}
+	// Defaults are 600 seconds and 10 revisions
+	if defaultInt32(d1.Spec.ProgressDeadlineSeconds, 600) != defaultInt32(d2.Spec.ProgressDeadlineSeconds, 600) {
+		return false
+	}
+	if defaultInt32(d1.Spec.RevisionHistoryLimit, 10) != defaultInt32(d2.Spec.RevisionHistoryLimit, 10) {
 		return false
 	}
 
 	// Pod Templates
-	t1s := d1.Spec.Template.Spec.DeepCopy()
-	t2s := d2.Spec.Template.Spec.DeepCopy()
-	// Image
-	if t1s.Containers[0].Image != t2s.Containers[0].Image {
+	return podTemplateEqual(d1.Spec.Template, d2.Spec.Template)
+}
+
+func StatefulSetEqual(s1, s2 appsv1.StatefulSet) bool {
+	if !labelsEqual(s1.ObjectMeta.Labels, s2.ObjectMeta.Labels) {
 		return false
 	}
-	// Env
-	if len(t1s.Containers[0].Env) != len(t2s.Containers[0].Env) {
+	if defaultInt32(s1.Spec.Replicas, 1) != defaultInt32(s2.Spec.Replicas, 1) {
 		return false
 	}
-	for i, e := range t1s.Containers[0].Env {
-		if t2s.Containers[0].Env[i] != e {
-			return false
-		}
+	if s1.Spec.ServiceName != s2.Spec.ServiceName {
+		return false
 	}
-	// Ports
-	if len(t1s.Containers[0].Ports) != len(t2s.Containers[0].Ports) {
+	if s1.Spec.MinReadySeconds != s2.Spec.MinReadySeconds {
 		return false
 	}
-	for i, p := range t1s.Containers[0].Ports {
-		if t2s.Containers[0].Ports[i] != p {
-			return false
-		}
+	if defaultInt32(s1.Spec.RevisionHistoryLimit, 10) != defaultInt32(s2.Spec.RevisionHistoryLimit, 10) {
+		return false
 	}
-	// VolumeMounts
-	if len(t1s.Containers[0].VolumeMounts) != len(t2s.Containers[0].VolumeMounts) {
+	if !VolumeClaimTemplatesEqual(s1.Spec.VolumeClaimTemplates, s2.Spec.VolumeClaimTemplates) {
+		return false
+	}
+	return podTemplateEqual(s1.Spec.Template, s2.Spec.Template)
+}
+
+func DaemonSetEqual(d1, d2 appsv1.DaemonSet) bool {
+	if !labelsEqual(d1.ObjectMeta.Labels, d2.ObjectMeta.Labels) {
+		return false
+	}
+	if !daemonSetStrategyEqual(d1.Spec.UpdateStrategy, d2.Spec.UpdateStrategy) {
+		return false
+	}
+	if d1.Spec.MinReadySeconds != d2.Spec.MinReadySeconds {
 		return false
 	}
-	for i, vM := range t1s.Containers[0].VolumeMounts {
-		if t2s.Containers[0].VolumeMounts[i].MountPath != vM.MountPath {
+	if defaultInt32(d1.Spec.RevisionHistoryLimit, 10) != defaultInt32(d2.Spec.RevisionHistoryLimit, 10) {
+		return false
+	}
+	return podTemplateEqual(d1.Spec.Template, d2.Spec.Template)
+}
+
+func VolumeClaimTemplatesEqual(c1, c2 []corev1.PersistentVolumeClaim) bool {
+	if len(c1) != len(c2) {
+		return false
+	}
+	for i, c := range c1 {
+		if c.Name != c2[i].Name {
 			return false
 		}
+		// A nil StorageClassName is replaced by the default class on creation
+		if c.Spec.StorageClassName != nil && defaultString(c2[i].Spec.StorageClassName, "") != *c.Spec.StorageClassName {
+			return false
+		}
+		if !equality.Semantic.DeepEqual(c.Spec.AccessModes, c2[i].Spec.AccessModes) {
+			return false
+		}
+		if !equality.Semantic.DeepEqual(c.Spec.Resources.Requests, c2[i].Spec.Resources.Requests) {
+			return false
+		}
+	}
+	return true
+}
+
+// Suspend is left to JobSuspendEqual, as it is the only field of a Job that
+// can be updated
+func JobEqual(j1, j2 batchv1.Job) bool {
+	if !labelsEqual(j1.ObjectMeta.Labels, j2.ObjectMeta.Labels) {
+		return false
+	}
+	return jobSpecEqual(j1.Spec, j2.Spec)
+}
+
+func JobSuspendEqual(j1, j2 batchv1.Job) bool {
+	return defaultBool(j1.Spec.Suspend, false) == defaultBool(j2.Spec.Suspend, false)
+}
+
+func CronJobEqual(c1, c2 batchv1.CronJob) bool {
+	if !labelsEqual(c1.ObjectMeta.Labels, c2.ObjectMeta.Labels) {
+		return false
+	}
+	if !labelsEqual(c1.Spec.JobTemplate.ObjectMeta.Labels, c2.Spec.JobTemplate.ObjectMeta.Labels) {
+		return false
+	}
+	if c1.Spec.Schedule != c2.Spec.Schedule {
+		return false
+	}
+	if defaultBool(c1.Spec.Suspend, false) != defaultBool(c2.Spec.Suspend, false) {
+		return false
+	}
+	if defaultString(c1.Spec.TimeZone, "") != defaultString(c2.Spec.TimeZone, "") {
+		return false
+	}
+	// Default ConcurrencyPolicy is Allow
+	concurrencyPolicy1, concurrencyPolicy2 := c1.Spec.ConcurrencyPolicy, c2.Spec.ConcurrencyPolicy
+	if concurrencyPolicy1 == "" {
+		concurrencyPolicy1 = batchv1.AllowConcurrent
+	}
+	if concurrencyPolicy2 == "" {
+		concurrencyPolicy2 = batchv1.AllowConcurrent
+	}
+	if concurrencyPolicy1 != concurrencyPolicy2 {
+		return false
+	}
+	if !equality.Semantic.DeepEqual(c1.Spec.StartingDeadlineSeconds, c2.Spec.StartingDeadlineSeconds) {
+		return false
+	}
+	// Defaults are 3 successful and 1 failed Jobs
+	if defaultInt32(c1.Spec.SuccessfulJobsHistoryLimit, 3) != defaultInt32(c2.Spec.SuccessfulJobsHistoryLimit, 3) {
+		return false
+	}
+	if defaultInt32(c1.Spec.FailedJobsHistoryLimit, 1) != defaultInt32(c2.Spec.FailedJobsHistoryLimit, 1) {
+		return false
+	}
+	return jobSpecEqual(c1.Spec.JobTemplate.Spec, c2.Spec.JobTemplate.Spec)
+}
+
+func jobSpecEqual(j1, j2 batchv1.JobSpec) bool {
+	// Default BackoffLimit is 6
+	if defaultInt32(j1.BackoffLimit, 6) != defaultInt32(j2.BackoffLimit, 6) {
+		return false
+	}
+	if !equality.Semantic.DeepEqual(j1.ActiveDeadlineSeconds, j2.ActiveDeadlineSeconds) {
+		return false
+	}
+	if !equality.Semantic.DeepEqual(j1.TTLSecondsAfterFinished, j2.TTLSecondsAfterFinished) {
+		return false
+	}
+	return podTemplateEqual(j1.Template, j2.Template)
+}
+
+// t1 is the desired template and t2 the existing one
+func podTemplateEqual(t1, t2 corev1.PodTemplateSpec) bool {
+	// Labels and Annotations
+	if !labelsEqual(t1.ObjectMeta.Labels, t2.ObjectMeta.Labels) {
+		return false
+	}
+	if !annotationsEqual(t1.ObjectMeta.Annotations, t2.ObjectMeta.Annotations) {
+		return false
+	}
+	t1s := t1.Spec.DeepCopy()
+	t2s := t2.Spec.DeepCopy()
+	// RestartPolicy, defaults to Always
+	restartPolicy1, restartPolicy2 := t1s.RestartPolicy, t2s.RestartPolicy
+	if restartPolicy1 == "" {
+		restartPolicy1 = corev1.RestartPolicyAlways
+	}
+	if restartPolicy2 == "" {
+		restartPolicy2 = corev1.RestartPolicyAlways
+	}
+	if restartPolicy1 != restartPolicy2 {
+		return false
+	}
+	// TerminationGracePeriodSeconds, defaults to 30
+	if defaultInt64(t1s.TerminationGracePeriodSeconds, 30) != defaultInt64(t2s.TerminationGracePeriodSeconds, 30) {
+		return false
+	}
+	// Containers
+	if !containersEqual(t1s.InitContainers, t2s.InitContainers) {
+		return false
+	}
+	if !containersEqual(t1s.Containers, t2s.Containers) {
+		return false
+	}
+	// Pod SecurityContext
+	if !podSecurityContextEqual(t1s.SecurityContext, t2s.SecurityContext) {
+		return false
+	}
+	// Scheduling
+	if !schedulingEqual(*t1s, *t2s) {
+		return false
+	}
+	// DNS, host aliases and runtime
+	if !podOptionsEqual(*t1s, *t2s) {
+		return false
 	}
 	// Volumes
 	if len(t1s.Volumes) != len(t2s.Volumes) { // Double check
@@ -62,9 +233,48 @@ func DeploymentEqual(d1, d2 appsv1.Deployment) bool {
 }
 
 func ServicesEqual(s1, s2 corev1.Service) bool {
+	if !labelsEqual(s1.ObjectMeta.Labels, s2.ObjectMeta.Labels) {
+		return false
+	}
 	if s1.Spec.Type != s2.Spec.Type {
 		return false
 	}
+	if !ServiceHeadlessEqual(s1, s2) {
+		return false
+	}
+	if !equality.Semantic.DeepEqual(s1.ObjectMeta.Annotations, s2.ObjectMeta.Annotations) {
+		return false
+	}
+	// Default SessionAffinity is None, with a 10800 seconds timeout for ClientIP
+	if defaultServiceAffinity(s1.Spec.SessionAffinity) != defaultServiceAffinity(s2.Spec.SessionAffinity) {
+		return false
+	}
+	if defaultServiceAffinity(s1.Spec.SessionAffinity) == corev1.ServiceAffinityClientIP && sessionAffinityTimeout(s1) != sessionAffinityTimeout(s2) {
+		return false
+	}
+	// Default ExternalTrafficPolicy is Cluster for Services with node ports
+	if s1.Spec.Type == corev1.ServiceTypeNodePort || s1.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		e1, e2 := s1.Spec.ExternalTrafficPolicy, s2.Spec.ExternalTrafficPolicy
+		if e1 == "" {
+			e1 = corev1.ServiceExternalTrafficPolicyCluster
+		}
+		if e2 == "" {
+			e2 = corev1.ServiceExternalTrafficPolicyCluster
+		}
+		if e1 != e2 {
+			return false
+		}
+	}
+	// Default InternalTrafficPolicy is Cluster
+	if defaultInternalTrafficPolicy(s1.Spec.InternalTrafficPolicy) != defaultInternalTrafficPolicy(s2.Spec.InternalTrafficPolicy) {
+		return false
+	}
+	if defaultString(s1.Spec.LoadBalancerClass, "") != defaultString(s2.Spec.LoadBalancerClass, "") {
+		return false
+	}
+	if !equality.Semantic.DeepEqual(s1.Spec.LoadBalancerSourceRanges, s2.Spec.LoadBalancerSourceRanges) {
+		return false
+	}
 	if len(s1.Spec.Selector) != len(s2.Spec.Selector) {
 		return false
 	}
@@ -77,13 +287,261 @@ func ServicesEqual(s1, s2 corev1.Service) bool {
 		return false
 	}
 	for i, p := range s1.Spec.Ports {
-		if !portsEqual(s2.Spec.Ports[i], p) {
+		if !portsEqual(p, s2.Spec.Ports[i]) {
 			return false
 		}
 	}
 	return true
 }
 
+// Compares labels, ignoring those added by the Job controller
+func labelsEqual(l1, l2 map[string]string) bool {
+	return equality.Semantic.DeepEqual(withoutJobLabels(l1), withoutJobLabels(l2))
+}
+
+func withoutJobLabels(l map[string]string) map[string]string {
+	filtered := make(map[string]string, len(l))
+	for key, value := range l {
+		if key == "controller-uid" || key == "job-name" || strings.HasPrefix(key, "batch.kubernetes.io/") {
+			continue
+		}
+		filtered[key] = value
+	}
+	return filtered
+}
+
+// Compares the desired annotations a1 with the existing a2. A restart by
+// kubectl rollout restart is kept unless the desired ones request their own.
+func annotationsEqual(a1, a2 map[string]string) bool {
+	if _, ok := a1[restartedAtAnnotation]; !ok {
+		if _, ok := a2[restartedAtAnnotation]; ok {
+			a2 = maps.Clone(a2)
+			delete(a2, restartedAtAnnotation)
+		}
+	}
+	return equality.Semantic.DeepEqual(a1, a2)
+}
+
+func containersEqual(c1, c2 []corev1.Container) bool {
+	if len(c1) != len(c2) {
+		return false
+	}
+	for i, c := range c1 {
+		if !containerEqual(c, c2[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func containerEqual(c1, c2 corev1.Container) bool {
+	if c1.Name != c2.Name {
+		return false
+	}
+	// Image
+	if c1.Image != c2.Image {
+		return false
+	}
+	// Command and Args
+	if !equality.Semantic.DeepEqual(c1.Command, c2.Command) || !equality.Semantic.DeepEqual(c1.Args, c2.Args) {
+		return false
+	}
+	// Env
+	if !equality.Semantic.DeepEqual(defaultEnv(c1.Env), defaultEnv(c2.Env)) {
+		return false
+	}
+	// Ports
+	if len(c1.Ports) != len(c2.Ports) {
+		return false
+	}
+	for i, p := range c1.Ports {
+		if !containerPortsEqual(c2.Ports[i], p) {
+			return false
+		}
+	}
+	// VolumeMounts
+	if len(c1.VolumeMounts) != len(c2.VolumeMounts) {
+		return false
+	}
+	for i, vM := range c1.VolumeMounts {
+		if !volumeMountsEqual(c2.VolumeMounts[i], vM) {
+			return false
+		}
+	}
+	// Resources
+	if !equality.Semantic.DeepEqual(c1.Resources, c2.Resources) {
+		return false
+	}
+	// Lifecycle
+	if !equality.Semantic.DeepEqual(defaultLifecycle(c1.Lifecycle), defaultLifecycle(c2.Lifecycle)) {
+		return false
+	}
+	// RestartPolicy, used by native sidecars
+	if !equality.Semantic.DeepEqual(c1.RestartPolicy, c2.RestartPolicy) {
+		return false
+	}
+	// SecurityContext
+	if !securityContextEqual(c1.SecurityContext, c2.SecurityContext) {
+		return false
+	}
+	return true
+}
+
+func containerPortsEqual(p1, p2 corev1.ContainerPort) bool {
+	// Default Protocol is TCP
+	if p1.Protocol == "" {
+		p1.Protocol = corev1.ProtocolTCP
+	}
+	if p2.Protocol == "" {
+		p2.Protocol = corev1.ProtocolTCP
+	}
+	return p1 == p2
+}
+
+func strategyEqual(s1, s2 appsv1.DeploymentStrategy) bool {
+	// Default strategy is RollingUpdate with 25% maxSurge and maxUnavailable
+	if s1.Type == "" {
+		s1.Type = appsv1.RollingUpdateDeploymentStrategyType
+	}
+	if s2.Type == "" {
+		s2.Type = appsv1.RollingUpdateDeploymentStrategyType
+	}
+	if s1.Type != s2.Type {
+		return false
+	}
+	if s1.Type != appsv1.RollingUpdateDeploymentStrategyType {
+		return true
+	}
+	defaultValue := intstr.FromString("25%")
+	var r1, r2 appsv1.RollingUpdateDeployment
+	if s1.RollingUpdate != nil {
+		r1 = *s1.RollingUpdate
+	}
+	if s2.RollingUpdate != nil {
+		r2 = *s2.RollingUpdate
+	}
+	if defaultIntOrString(r1.MaxSurge, defaultValue) != defaultIntOrString(r2.MaxSurge, defaultValue) {
+		return false
+	}
+	if defaultIntOrString(r1.MaxUnavailable, defaultValue) != defaultIntOrString(r2.MaxUnavailable, defaultValue) {
+		return false
+	}
+	return true
+}
+
+func daemonSetStrategyEqual(s1, s2 appsv1.DaemonSetUpdateStrategy) bool {
+	// Default strategy is RollingUpdate with 0 maxSurge and 1 maxUnavailable
+	if s1.Type == "" {
+		s1.Type = appsv1.RollingUpdateDaemonSetStrategyType
+	}
+	if s2.Type == "" {
+		s2.Type = appsv1.RollingUpdateDaemonSetStrategyType
+	}
+	if s1.Type != s2.Type {
+		return false
+	}
+	if s1.Type != appsv1.RollingUpdateDaemonSetStrategyType {
+		return true
+	}
+	var r1, r2 appsv1.RollingUpdateDaemonSet
+	if s1.RollingUpdate != nil {
+		r1 = *s1.RollingUpdate
+	}
+	if s2.RollingUpdate != nil {
+		r2 = *s2.RollingUpdate
+	}
+	if defaultIntOrString(r1.MaxSurge, intstr.FromInt32(0)) != defaultIntOrString(r2.MaxSurge, intstr.FromInt32(0)) {
+		return false
+	}
+	if defaultIntOrString(r1.MaxUnavailable, intstr.FromInt32(1)) != defaultIntOrString(r2.MaxUnavailable, intstr.FromInt32(1)) {
+		return false
+	}
+	return true
+}
+
+func podSecurityContextEqual(c1, c2 *corev1.PodSecurityContext) bool {
+	// The API server defaults a missing Pod securityContext to an empty one
+	if c1 == nil {
+		c1 = &corev1.PodSecurityContext{}
+	}
+	if c2 == nil {
+		c2 = &corev1.PodSecurityContext{}
+	}
+	return equality.Semantic.DeepEqual(c1, c2)
+}
+
+func securityContextEqual(c1, c2 *corev1.SecurityContext) bool {
+	if c1 == nil {
+		c1 = &corev1.SecurityContext{}
+	}
+	if c2 == nil {
+		c2 = &corev1.SecurityContext{}
+	}
+	return equality.Semantic.DeepEqual(c1, c2)
+}
+
+func schedulingEqual(p1, p2 corev1.PodSpec) bool {
+	if !equality.Semantic.DeepEqual(p1.NodeSelector, p2.NodeSelector) {
+		return false
+	}
+	if !equality.Semantic.DeepEqual(p1.Tolerations, p2.Tolerations) {
+		return false
+	}
+	if !equality.Semantic.DeepEqual(p1.Affinity, p2.Affinity) {
+		return false
+	}
+	if p1.PriorityClassName != p2.PriorityClassName {
+		return false
+	}
+	if !equality.Semantic.DeepEqual(p1.TopologySpreadConstraints, p2.TopologySpreadConstraints) {
+		return false
+	}
+	return true
+}
+
+func podOptionsEqual(p1, p2 corev1.PodSpec) bool {
+	// Default DNSPolicy is ClusterFirst
+	if p1.DNSPolicy == "" {
+		p1.DNSPolicy = corev1.DNSClusterFirst
+	}
+	if p2.DNSPolicy == "" {
+		p2.DNSPolicy = corev1.DNSClusterFirst
+	}
+	if p1.DNSPolicy != p2.DNSPolicy {
+		return false
+	}
+	if !equality.Semantic.DeepEqual(p1.DNSConfig, p2.DNSConfig) {
+		return false
+	}
+	if !equality.Semantic.DeepEqual(p1.HostAliases, p2.HostAliases) {
+		return false
+	}
+	if defaultString(p1.RuntimeClassName, "") != defaultString(p2.RuntimeClassName, "") {
+		return false
+	}
+	if defaultBool(p1.ShareProcessNamespace, false) != defaultBool(p2.ShareProcessNamespace, false) {
+		return false
+	}
+	return p1.HostNetwork == p2.HostNetwork
+}
+
+func volumeMountsEqual(m1, m2 corev1.VolumeMount) bool {
+	if m1.Name != m2.Name || m1.MountPath != m2.MountPath {
+		return false
+	}
+	if m1.SubPath != m2.SubPath || m1.SubPathExpr != m2.SubPathExpr {
+		return false
+	}
+	if m1.ReadOnly != m2.ReadOnly {
+		return false
+	}
+	// Default MountPropagation is None
+	if defaultString((*string)(m1.MountPropagation), string(corev1.MountPropagationNone)) != defaultString((*string)(m2.MountPropagation), string(corev1.MountPropagationNone)) {
+		return false
+	}
+	return true
+}
+
 func volumesEqual(v1, v2 corev1.Volume) bool {
 	if v1.Name != v2.Name {
 		return false
@@ -207,9 +665,148 @@ func volumesEqual(v1, v2 corev1.Volume) bool {
 		}
 	}
 
+	// DownwardAPI
+	if v1.DownwardAPI != nil {
+		if v2.DownwardAPI == nil {
+			return false
+		}
+		if defaultInt32(v1.DownwardAPI.DefaultMode, 420) != defaultInt32(v2.DownwardAPI.DefaultMode, 420) {
+			return false
+		}
+		if !equality.Semantic.DeepEqual(defaultDownwardAPIFiles(v1.DownwardAPI.Items), defaultDownwardAPIFiles(v2.DownwardAPI.Items)) {
+			return false
+		}
+	}
+
+	// Projected
+	if v1.Projected != nil {
+		if v2.Projected == nil {
+			return false
+		}
+		if defaultInt32(v1.Projected.DefaultMode, 420) != defaultInt32(v2.Projected.DefaultMode, 420) {
+			return false
+		}
+		if !equality.Semantic.DeepEqual(defaultProjections(v1.Projected.Sources), defaultProjections(v2.Projected.Sources)) {
+			return false
+		}
+	}
+
+	// HostPath
+	if v1.HostPath != nil {
+		if v2.HostPath == nil {
+			return false
+		}
+		if v1.HostPath.Path != v2.HostPath.Path {
+			return false
+		}
+		if defaultString((*string)(v1.HostPath.Type), "") != defaultString((*string)(v2.HostPath.Type), "") {
+			return false
+		}
+	}
+
+	// Ephemeral
+	if v1.Ephemeral != nil {
+		if v2.Ephemeral == nil || v1.Ephemeral.VolumeClaimTemplate == nil || v2.Ephemeral.VolumeClaimTemplate == nil {
+			return false
+		}
+		if !labelsEqual(v1.Ephemeral.VolumeClaimTemplate.Labels, v2.Ephemeral.VolumeClaimTemplate.Labels) {
+			return false
+		}
+		if !claimSpecEqual(v1.Ephemeral.VolumeClaimTemplate.Spec, v2.Ephemeral.VolumeClaimTemplate.Spec) {
+			return false
+		}
+	}
+
+	// Image
+	if v1.Image != nil {
+		if v2.Image == nil {
+			return false
+		}
+		if v1.Image.Reference != v2.Image.Reference {
+			return false
+		}
+		if defaultPullPolicy(v1.Image.PullPolicy, v1.Image.Reference) != defaultPullPolicy(v2.Image.PullPolicy, v2.Image.Reference) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func claimSpecEqual(s1, s2 corev1.PersistentVolumeClaimSpec) bool {
+	if defaultString(s1.StorageClassName, "") != defaultString(s2.StorageClassName, "") {
+		return false
+	}
+	if !equality.Semantic.DeepEqual(s1.AccessModes, s2.AccessModes) {
+		return false
+	}
+	if !equality.Semantic.DeepEqual(s1.Resources.Requests, s2.Resources.Requests) {
+		return false
+	}
+	// Default VolumeMode is Filesystem
+	if defaultString((*string)(s1.VolumeMode), string(corev1.PersistentVolumeFilesystem)) != defaultString((*string)(s2.VolumeMode), string(corev1.PersistentVolumeFilesystem)) {
+		return false
+	}
 	return true
 }
 
+// Fills in the fieldRef apiVersion defaulted by the API server
+func defaultEnv(env []corev1.EnvVar) []corev1.EnvVar {
+	defaulted := make([]corev1.EnvVar, len(env))
+	for i, variable := range env {
+		variable.DeepCopyInto(&defaulted[i])
+		valueFrom := defaulted[i].ValueFrom
+		if valueFrom != nil && valueFrom.FieldRef != nil && valueFrom.FieldRef.APIVersion == "" {
+			valueFrom.FieldRef.APIVersion = "v1"
+		}
+	}
+	return defaulted
+}
+
+// Fills in the fieldRef apiVersion defaulted by the API server
+func defaultDownwardAPIFiles(files []corev1.DownwardAPIVolumeFile) []corev1.DownwardAPIVolumeFile {
+	defaulted := make([]corev1.DownwardAPIVolumeFile, len(files))
+	for i, file := range files {
+		file.DeepCopyInto(&defaulted[i])
+		if defaulted[i].FieldRef != nil && defaulted[i].FieldRef.APIVersion == "" {
+			defaulted[i].FieldRef.APIVersion = "v1"
+		}
+	}
+	return defaulted
+}
+
+// Fills in the defaults the API server sets on projected volume sources
+func defaultProjections(projections []corev1.VolumeProjection) []corev1.VolumeProjection {
+	defaulted := make([]corev1.VolumeProjection, len(projections))
+	for i, projection := range projections {
+		projection.DeepCopyInto(&defaulted[i])
+		if defaulted[i].DownwardAPI != nil {
+			defaulted[i].DownwardAPI.Items = defaultDownwardAPIFiles(defaulted[i].DownwardAPI.Items)
+		}
+		if token := defaulted[i].ServiceAccountToken; token != nil && token.ExpirationSeconds == nil {
+			expirationSeconds := int64(3600)
+			token.ExpirationSeconds = &expirationSeconds
+		}
+	}
+	return defaulted
+}
+
+// Images tagged latest or without a tag are always pulled by default
+func defaultPullPolicy(p corev1.PullPolicy, image string) corev1.PullPolicy {
+	if p != "" {
+		return p
+	}
+	if i := strings.Index(image, "@"); i >= 0 {
+		return corev1.PullIfNotPresent
+	}
+	colon := strings.LastIndex(image, ":")
+	if colon <= strings.LastIndex(image, "/") || image[colon+1:] == "latest" {
+		return corev1.PullAlways
+	}
+	return corev1.PullIfNotPresent
+}
+
+// p1 is the desired port and p2 the existing one
 func portsEqual(p1, p2 corev1.ServicePort) bool {
 	if p1.Name != p2.Name {
 		return false
@@ -223,9 +820,61 @@ func portsEqual(p1, p2 corev1.ServicePort) bool {
 	if p1.TargetPort != p2.TargetPort {
 		return false
 	}
+	// Unpinned node ports are allocated by the API server
+	if p1.NodePort != 0 && p1.NodePort != p2.NodePort {
+		return false
+	}
 	return true
 }
 
+// Whether both Services are headless or both have a cluster IP. This cannot
+// be changed on an existing Service.
+func ServiceHeadlessEqual(s1, s2 corev1.Service) bool {
+	return (s1.Spec.ClusterIP == corev1.ClusterIPNone) == (s2.Spec.ClusterIP == corev1.ClusterIPNone)
+}
+
+func defaultServiceAffinity(a corev1.ServiceAffinity) corev1.ServiceAffinity {
+	if a == "" {
+		return corev1.ServiceAffinityNone
+	}
+	return a
+}
+
+func sessionAffinityTimeout(s corev1.Service) int32 {
+	if s.Spec.SessionAffinityConfig == nil || s.Spec.SessionAffinityConfig.ClientIP == nil {
+		return corev1.DefaultClientIPServiceAffinitySeconds
+	}
+	return defaultInt32(s.Spec.SessionAffinityConfig.ClientIP.TimeoutSeconds, corev1.DefaultClientIPServiceAffinitySeconds)
+}
+
+func defaultInternalTrafficPolicy(p *corev1.ServiceInternalTrafficPolicy) corev1.ServiceInternalTrafficPolicy {
+	if p == nil {
+		return corev1.ServiceInternalTrafficPolicyCluster
+	}
+	return *p
+}
+
+// Fills in the scheme of HTTP hooks, defaulted by the API server
+func defaultLifecycle(l *corev1.Lifecycle) *corev1.Lifecycle {
+	if l == nil || (l.PostStart == nil && l.PreStop == nil) {
+		return nil
+	}
+	defaulted := l.DeepCopy()
+	for _, handler := range []*corev1.LifecycleHandler{defaulted.PostStart, defaulted.PreStop} {
+		if handler != nil && handler.HTTPGet != nil && handler.HTTPGet.Scheme == "" {
+			handler.HTTPGet.Scheme = corev1.URISchemeHTTP
+		}
+	}
+	return defaulted
+}
+
+func defaultInt64(i *int64, def int64) int64 {
+	if i == nil {
+		return def
+	}
+	return *i
+}
+
 func defaultBool(b *bool, def bool) bool {
 	if b == nil {
 		return def
@@ -233,6 +882,20 @@ func defaultBool(b *bool, def bool) bool {
 	return *b
 }
 
+func defaultInt32(i *int32, def int32) int32 {
+	if i == nil {
+		return def
+	}
+	return *i
+}
+
+func defaultIntOrString(i *intstr.IntOrString, def intstr.IntOrString) intstr.IntOrString {
+	if i == nil {
+		return def
+	}
+	return *i
+}
+
 func defaultString(s *string, def string) string {
 	if s == nil {
 		return def
@@ -0,0 +1,358 @@
+package utils
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func intOrStringPtr(i intstr.IntOrString) *intstr.IntOrString {
+	return &i
+}
+
+func testDeployment(spec appsv1.DeploymentSpec) appsv1.Deployment {
+	if spec.Replicas == nil {
+		spec.Replicas = int32Ptr(1)
+	}
+	spec.Template.Spec.Containers = []corev1.Container{{Name: "test", Image: "nginx:1.27"}}
+	return appsv1.Deployment{Spec: spec}
+}
+
+func TestDeploymentEqualRollout(t *testing.T) {
+	// As returned by the API server, with every default filled in
+	current := testDeployment(appsv1.DeploymentSpec{
+		Strategy: appsv1.DeploymentStrategy{
+			Type: appsv1.RollingUpdateDeploymentStrategyType,
+			RollingUpdate: &appsv1.RollingUpdateDeployment{
+				MaxSurge:       intOrStringPtr(intstr.FromString("25%")),
+				MaxUnavailable: intOrStringPtr(intstr.FromString("25%")),
+			},
+		},
+		ProgressDeadlineSeconds: int32Ptr(600),
+		RevisionHistoryLimit:    int32Ptr(10),
+	})
+	tests := []struct {
+		name    string
+		desired appsv1.DeploymentSpec
+		want    bool
+	}{
+		{"defaults", appsv1.DeploymentSpec{}, true},
+		{"explicit rolling update", appsv1.DeploymentSpec{
+			Strategy: appsv1.DeploymentStrategy{Type: appsv1.RollingUpdateDeploymentStrategyType},
+		}, true},
+		{"maxSurge", appsv1.DeploymentSpec{
+			Strategy: appsv1.DeploymentStrategy{
+				Type:          appsv1.RollingUpdateDeploymentStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateDeployment{MaxSurge: intOrStringPtr(intstr.FromInt32(1))},
+			},
+		}, false},
+		{"recreate", appsv1.DeploymentSpec{
+			Strategy: appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
+		}, false},
+		{"minReadySeconds", appsv1.DeploymentSpec{MinReadySeconds: 10}, false},
+		{"progressDeadlineSeconds", appsv1.DeploymentSpec{ProgressDeadlineSeconds: int32Ptr(600)}, true},
+		{"revisionHistoryLimit", appsv1.DeploymentSpec{RevisionHistoryLimit: int32Ptr(2)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DeploymentEqual(testDeployment(tt.desired), current); got != tt.want {
+				t.Errorf("DeploymentEqual() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func claimTemplate(name string, storageClassName *string, size string) corev1.PersistentVolumeClaim {
+	return corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: storageClassName,
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+			},
+		},
+	}
+}
+
+func TestVolumeClaimTemplatesEqual(t *testing.T) {
+	standard, fast := "standard", "fast"
+	// The claim template as stored, after the default class was filled in
+	current := []corev1.PersistentVolumeClaim{claimTemplate("data", &standard, "1Gi")}
+	tests := []struct {
+		name    string
+		desired []corev1.PersistentVolumeClaim
+		want    bool
+	}{
+		{"default storage class", []corev1.PersistentVolumeClaim{claimTemplate("data", nil, "1Gi")}, true},
+		{"same storage class", []corev1.PersistentVolumeClaim{claimTemplate("data", &standard, "1Gi")}, true},
+		{"other storage class", []corev1.PersistentVolumeClaim{claimTemplate("data", &fast, "1Gi")}, false},
+		{"same size in other units", []corev1.PersistentVolumeClaim{claimTemplate("data", nil, "1024Mi")}, true},
+		{"larger size", []corev1.PersistentVolumeClaim{claimTemplate("data", nil, "2Gi")}, false},
+		{"renamed", []corev1.PersistentVolumeClaim{claimTemplate("logs", nil, "1Gi")}, false},
+		{"added", []corev1.PersistentVolumeClaim{claimTemplate("data", nil, "1Gi"), claimTemplate("logs", nil, "1Gi")}, false},
+		{"removed", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VolumeClaimTemplatesEqual(tt.desired, current); got != tt.want {
+				t.Errorf("VolumeClaimTemplatesEqual() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func testJobSpec() batchv1.JobSpec {
+	return batchv1.JobSpec{
+		Template: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{
+				RestartPolicy: corev1.RestartPolicyOnFailure,
+				Containers:    []corev1.Container{{Name: "test", Image: "busybox:1.37"}},
+			},
+		},
+	}
+}
+
+func TestJobEqual(t *testing.T) {
+	current := batchv1.Job{Spec: testJobSpec()}
+	current.Spec.BackoffLimit = int32Ptr(6)
+
+	desired := batchv1.Job{Spec: testJobSpec()}
+	if !JobEqual(desired, current) {
+		t.Error("JobEqual() = false with the default backoffLimit")
+	}
+	desired.Spec.TTLSecondsAfterFinished = int32Ptr(60)
+	if JobEqual(desired, current) {
+		t.Error("JobEqual() = true with a new ttlSecondsAfterFinished")
+	}
+	desired = batchv1.Job{Spec: testJobSpec()}
+	desired.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+	if JobEqual(desired, current) {
+		t.Error("JobEqual() = true with a new restartPolicy")
+	}
+}
+
+func TestCronJobEqual(t *testing.T) {
+	current := batchv1.CronJob{
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   "0 * * * *",
+			ConcurrencyPolicy:          batchv1.AllowConcurrent,
+			SuccessfulJobsHistoryLimit: int32Ptr(3),
+			FailedJobsHistoryLimit:     int32Ptr(1),
+			JobTemplate:                batchv1.JobTemplateSpec{Spec: testJobSpec()},
+		},
+	}
+	current.Spec.JobTemplate.Spec.BackoffLimit = int32Ptr(6)
+
+	tests := []struct {
+		name   string
+		change func(spec *batchv1.CronJobSpec)
+		want   bool
+	}{
+		{"defaults", func(spec *batchv1.CronJobSpec) {}, true},
+		{"schedule", func(spec *batchv1.CronJobSpec) { spec.Schedule = "*/5 * * * *" }, false},
+		{"timeZone", func(spec *batchv1.CronJobSpec) { timeZone := "Europe/Madrid"; spec.TimeZone = &timeZone }, false},
+		{"concurrencyPolicy", func(spec *batchv1.CronJobSpec) { spec.ConcurrencyPolicy = batchv1.ForbidConcurrent }, false},
+		{"successfulJobsHistoryLimit", func(spec *batchv1.CronJobSpec) { spec.SuccessfulJobsHistoryLimit = int32Ptr(3) }, true},
+		{"failedJobsHistoryLimit", func(spec *batchv1.CronJobSpec) { spec.FailedJobsHistoryLimit = int32Ptr(0) }, false},
+		{"backoffLimit", func(spec *batchv1.CronJobSpec) { spec.JobTemplate.Spec.BackoffLimit = int32Ptr(0) }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired := batchv1.CronJob{
+				Spec: batchv1.CronJobSpec{
+					Schedule:    "0 * * * *",
+					JobTemplate: batchv1.JobTemplateSpec{Spec: testJobSpec()},
+				},
+			}
+			tt.change(&desired.Spec)
+			if got := CronJobEqual(desired, current); got != tt.want {
+				t.Errorf("CronJobEqual() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDaemonSetEqualUpdateStrategy(t *testing.T) {
+	template := corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "test", Image: "fluent-bit:4.0"}}},
+	}
+	current := appsv1.DaemonSet{
+		Spec: appsv1.DaemonSetSpec{
+			UpdateStrategy: appsv1.DaemonSetUpdateStrategy{
+				Type: appsv1.RollingUpdateDaemonSetStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateDaemonSet{
+					MaxSurge:       intOrStringPtr(intstr.FromInt32(0)),
+					MaxUnavailable: intOrStringPtr(intstr.FromInt32(1)),
+				},
+			},
+			RevisionHistoryLimit: int32Ptr(10),
+			Template:             template,
+		},
+	}
+	tests := []struct {
+		name     string
+		strategy appsv1.DaemonSetUpdateStrategy
+		want     bool
+	}{
+		{"default", appsv1.DaemonSetUpdateStrategy{}, true},
+		{"rolling update", appsv1.DaemonSetUpdateStrategy{Type: appsv1.RollingUpdateDaemonSetStrategyType}, true},
+		{"onDelete", appsv1.DaemonSetUpdateStrategy{Type: appsv1.OnDeleteDaemonSetStrategyType}, false},
+		{"maxUnavailable 1", appsv1.DaemonSetUpdateStrategy{
+			RollingUpdate: &appsv1.RollingUpdateDaemonSet{MaxUnavailable: intOrStringPtr(intstr.FromInt32(1))},
+		}, true},
+		{"maxUnavailable 10%", appsv1.DaemonSetUpdateStrategy{
+			RollingUpdate: &appsv1.RollingUpdateDaemonSet{MaxUnavailable: intOrStringPtr(intstr.FromString("10%"))},
+		}, false},
+		{"maxSurge", appsv1.DaemonSetUpdateStrategy{
+			RollingUpdate: &appsv1.RollingUpdateDaemonSet{MaxSurge: intOrStringPtr(intstr.FromInt32(1)), MaxUnavailable: intOrStringPtr(intstr.FromInt32(0))},
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired := appsv1.DaemonSet{Spec: appsv1.DaemonSetSpec{UpdateStrategy: tt.strategy, Template: template}}
+			if got := DaemonSetEqual(desired, current); got != tt.want {
+				t.Errorf("DaemonSetEqual() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuspendEqual(t *testing.T) {
+	suspended, running := true, false
+
+	job := batchv1.Job{Spec: testJobSpec()}
+	suspendedJob := batchv1.Job{Spec: testJobSpec()}
+	suspendedJob.Spec.Suspend = &suspended
+	if !JobEqual(suspendedJob, job) {
+		t.Error("JobEqual() = false when only suspend changed")
+	}
+	if JobSuspendEqual(suspendedJob, job) {
+		t.Error("JobSuspendEqual() = true for a suspended and a running Job")
+	}
+	job.Spec.Suspend = &running
+	if !JobSuspendEqual(batchv1.Job{Spec: testJobSpec()}, job) {
+		t.Error("JobSuspendEqual() = false for an unset and a false suspend")
+	}
+
+	cronJob := batchv1.CronJob{Spec: batchv1.CronJobSpec{Schedule: "0 * * * *", Suspend: &running, JobTemplate: batchv1.JobTemplateSpec{Spec: testJobSpec()}}}
+	suspendedCronJob := *cronJob.DeepCopy()
+	suspendedCronJob.Spec.Suspend = &suspended
+	if CronJobEqual(suspendedCronJob, cronJob) {
+		t.Error("CronJobEqual() = true for a suspended and a running CronJob")
+	}
+
+	deployment := testDeployment(appsv1.DeploymentSpec{})
+	paused := testDeployment(appsv1.DeploymentSpec{Paused: true})
+	if DeploymentEqual(paused, deployment) {
+		t.Error("DeploymentEqual() = true for a paused and a running Deployment")
+	}
+}
+
+func testService(nodePort int32) corev1.Service {
+	return corev1.Service{
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeNodePort,
+			Selector: map[string]string{"app": "test"},
+			Ports: []corev1.ServicePort{{
+				Name:       "http",
+				Protocol:   corev1.ProtocolTCP,
+				Port:       80,
+				TargetPort: intstr.FromInt32(8080),
+				NodePort:   nodePort,
+			}},
+		},
+	}
+}
+
+func TestServicesEqual(t *testing.T) {
+	tests := []struct {
+		name             string
+		desired, current corev1.Service
+		want             bool
+	}{
+		{"unpinned node port allocated", testService(0), testService(30080), true},
+		{"pinned node port unchanged", testService(30080), testService(30080), true},
+		{"pinned node port changed", testService(30081), testService(30080), false},
+		{"node port pinned", testService(30080), testService(31000), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ServicesEqual(tt.desired, tt.current); got != tt.want {
+				t.Errorf("ServicesEqual() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func testContainer(apiVersion string) corev1.Container {
+	return corev1.Container{
+		Name:  "test",
+		Image: "nginx:1.27",
+		Env: []corev1.EnvVar{
+			{Name: "PLAIN", Value: "value"},
+			{Name: "POD_NAME", ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{APIVersion: apiVersion, FieldPath: "metadata.name"},
+			}},
+		},
+	}
+}
+
+func TestContainerEqualEnv(t *testing.T) {
+	tests := []struct {
+		name             string
+		desired, current corev1.Container
+		want             bool
+	}{
+		{"fieldRef apiVersion defaulted", testContainer(""), testContainer("v1"), true},
+		{"fieldRef apiVersion explicit", testContainer("v1"), testContainer("v1"), true},
+		{"fieldRef apiVersion changed", testContainer("v2"), testContainer("v1"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := containerEqual(tt.desired, tt.current); got != tt.want {
+				t.Errorf("containerEqual() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeploymentEqualRestartedAt(t *testing.T) {
+	withAnnotations := func(annotations map[string]string) appsv1.Deployment {
+		deployment := testDeployment(appsv1.DeploymentSpec{})
+		deployment.Spec.Template.Annotations = annotations
+		return deployment
+	}
+	tests := []struct {
+		name     string
+		desired  map[string]string
+		existing map[string]string
+		want     bool
+	}{
+		{"kubectl rollout restart", nil, map[string]string{restartedAtAnnotation: "2026-01-02T03:04:05Z"}, true},
+		{"kubectl rollout restart with other annotations", map[string]string{"a": "1"}, map[string]string{"a": "1", restartedAtAnnotation: "2026-01-02T03:04:05Z"}, true},
+		{"restart requested", map[string]string{restartedAtAnnotation: "2026-01-02T03:04:05Z"}, nil, false},
+		{"new restart requested", map[string]string{restartedAtAnnotation: "2026-02-02T03:04:05Z"}, map[string]string{restartedAtAnnotation: "2026-01-02T03:04:05Z"}, false},
+		{"other annotation removed", nil, map[string]string{"a": "1", restartedAtAnnotation: "2026-01-02T03:04:05Z"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := withAnnotations(tt.existing)
+			if got := DeploymentEqual(withAnnotations(tt.desired), existing); got != tt.want {
+				t.Errorf("DeploymentEqual() = %v, want %v", got, tt.want)
+			}
+			if _, ok := tt.existing[restartedAtAnnotation]; tt.existing != nil && !ok {
+				t.Error("the existing annotations were modified")
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/pecio/simpleapp/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Comma separated Service types SimpleApps may use, set from the command line
+var allowedServiceTypes = "ClusterIP,NodePort,LoadBalancer"
+
+func serviceTypeAllowed(serviceType corev1.ServiceType) bool {
+	if serviceType == "" {
+		serviceType = corev1.ServiceTypeClusterIP
+	}
+	for _, allowed := range strings.Split(allowedServiceTypes, ",") {
+		if strings.TrimSpace(allowed) == string(serviceType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks a SimpleApp for the problems otherwise found when reconciling it.
+// Missing ConfigMaps and Secrets are only warnings, as they may be created
+// after the SimpleApp.
+func (sa *SimpleApp) validate(clientset kubernetes.Interface) ([]string, []string) {
+	errs := sa.checkSpec()
+	errs = append(errs, sa.checkReplicas()...)
+
+	// Anything else the reconciler would fail on
+	if len(errs) == 0 {
+		err := sa.checkBuild()
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	return errs, sa.missingReferences(clientset)
+}
+
+// Checks for duplicate ports and volumes, disallowed Service types and
+// unsupported suspend options
+func (sa *SimpleApp) checkSpec() []string {
+	errs := sa.checkSuspend()
+
+	for i, port := range sa.Spec.Ports {
+		for _, previous := range sa.Spec.Ports[:i] {
+			if port.HostPort == previous.HostPort && defaultProtocol(port.Protocol) == defaultProtocol(previous.Protocol) {
+				errs = append(errs, fmt.Sprintf("ports[%d]: duplicate hostPort %v/%v", i, port.HostPort, defaultProtocol(port.Protocol)))
+			}
+			// Service port names are truncated to 13 characters
+			if port.Name != "" && fmt.Sprintf("%.13v", port.Name) == fmt.Sprintf("%.13v", previous.Name) {
+				errs = append(errs, fmt.Sprintf("ports[%d]: name %v collides with %v once truncated to 13 characters", i, port.Name, previous.Name))
+			}
+		}
+	}
+
+	if !serviceTypeAllowed(sa.Spec.ServiceType) {
+		errs = append(errs, fmt.Sprintf("serviceType: %v is not allowed", sa.Spec.ServiceType))
+	}
+	for i, saNamedService := range sa.Spec.Services {
+		if !serviceTypeAllowed(saNamedService.Type) {
+			errs = append(errs, fmt.Sprintf("services[%d].type: %v is not allowed", i, saNamedService.Type))
+		}
+	}
+
+	mountPaths := make(map[string]struct{})
+	for i, saVolume := range sa.Spec.Volumes {
+		if volumeSources(&saVolume) != 1 {
+			errs = append(errs, fmt.Sprintf("volumes[%d]: volume for path %v must have exactly one type", i, saVolume.MountPath))
+		}
+		for _, volumeMount := range buildVolumeMounts(&saVolume) {
+			if _, ok := mountPaths[volumeMount.MountPath]; ok {
+				errs = append(errs, fmt.Sprintf("volumes[%d]: duplicate mountPath %v", i, volumeMount.MountPath))
+			}
+			mountPaths[volumeMount.MountPath] = struct{}{}
+		}
+	}
+	return errs
+}
+
+// Only Deployments and StatefulSets scale. Not part of checkSpec, as replicas
+// used to be ignored for other workloads and those SimpleApps still work.
+func (sa *SimpleApp) checkReplicas() []string {
+	if sa.Spec.Replicas == nil || *sa.Spec.Replicas == 1 {
+		return nil
+	}
+	switch sa.workloadType() {
+	case workloadDeployment, workloadStatefulSet:
+		return nil
+	}
+	return []string{fmt.Sprintf("replicas: only supported with workloadType Deployment or StatefulSet, not %v", sa.workloadType())}
+}
+
+func defaultProtocol(protocol corev1.Protocol) corev1.Protocol {
+	if protocol == "" {
+		return corev1.ProtocolTCP
+	}
+	return protocol
+}
+
+// Number of volume sources set
+func volumeSources(v *v1alpha1.SimpleAppVolume) int {
+	sources := 0
+	for _, set := range []bool{
+		v.EmptyDir != nil,
+		v.ConfigMap != nil,
+		v.PersistentVolumeClaim != nil,
+		v.Secret != nil,
+		v.CSI != nil,
+		v.VolumeClaimTemplate != nil,
+		v.DownwardAPI != nil,
+		v.Projected != nil,
+		v.HostPath != nil,
+		v.Ephemeral != nil,
+		v.Image != nil,
+	} {
+		if set {
+			sources++
+		}
+	}
+	return sources
+}
+
+// Builds every object the reconciler would, discarding them
+func (sa *SimpleApp) checkBuild() error {
+	var err error
+	switch sa.workloadType() {
+	case workloadDeployment:
+		_, err = sa.buildDeployment()
+	case workloadStatefulSet:
+		_, err = sa.buildStatefulSet()
+	case workloadDaemonSet:
+		_, err = sa.buildDaemonSet()
+	case workloadJob:
+		_, err = sa.buildJob()
+	case workloadCronJob:
+		_, err = sa.buildCronJob()
+	default:
+		err = fmt.Errorf("unknown workloadType %v in %v.%v", sa.Spec.WorkloadType, sa.Namespace, sa.Name)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = sa.buildServices()
+	if err != nil {
+		return err
+	}
+	_, err = sa.buildPersistentVolumeClaims()
+	return err
+}
+
+// Warns about ConfigMaps and Secrets referenced by volumes or environment
+// variables that do not exist, unless they are optional
+func (sa *SimpleApp) missingReferences(clientset kubernetes.Interface) []string {
+	configMaps := make(map[string]struct{})
+	secrets := make(map[string]struct{})
+	for _, saVolume := range sa.Spec.Volumes {
+		if saVolume.ConfigMap != nil && !isTrue(saVolume.ConfigMap.Optional) {
+			configMaps[saVolume.ConfigMap.Name] = struct{}{}
+		}
+		if saVolume.Secret != nil && !isTrue(saVolume.Secret.Optional) {
+			secrets[saVolume.Secret.Name] = struct{}{}
+		}
+		if saVolume.Projected != nil {
+			for _, source := range saVolume.Projected.Sources {
+				if source.ConfigMap != nil && !isTrue(source.ConfigMap.Optional) {
+					configMaps[source.ConfigMap.Name] = struct{}{}
+				}
+				if source.Secret != nil && !isTrue(source.Secret.Optional) {
+					secrets[source.Secret.Name] = struct{}{}
+				}
+			}
+		}
+	}
+	envs := [][]corev1.EnvVar{sa.Spec.Env}
+	for _, saContainer := range append(append([]v1alpha1.SimpleAppContainer{}, sa.Spec.InitContainers...), sa.Spec.Sidecars...) {
+		envs = append(envs, saContainer.Env)
+	}
+	for _, env := range envs {
+		for _, envVar := range env {
+			if envVar.ValueFrom == nil {
+				continue
+			}
+			if ref := envVar.ValueFrom.ConfigMapKeyRef; ref != nil && !isTrue(ref.Optional) {
+				configMaps[ref.Name] = struct{}{}
+			}
+			if ref := envVar.ValueFrom.SecretKeyRef; ref != nil && !isTrue(ref.Optional) {
+				secrets[ref.Name] = struct{}{}
+			}
+		}
+	}
+
+	warnings := make([]string, 0)
+	for _, name := range sortedKeys(configMaps) {
+		_, err := clientset.CoreV1().ConfigMaps(sa.Namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			warnings = append(warnings, fmt.Sprintf("ConfigMap %v not found", name))
+		} else if err != nil {
+			log.Printf("Got %v checking ConfigMap %v.%v", err, sa.Namespace, name)
+		}
+	}
+	for _, name := range sortedKeys(secrets) {
+		_, err := clientset.CoreV1().Secrets(sa.Namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			warnings = append(warnings, fmt.Sprintf("Secret %v not found", name))
+		} else if err != nil {
+			log.Printf("Got %v checking Secret %v.%v", err, sa.Namespace, name)
+		}
+	}
+	return warnings
+}
+
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidateAdmission(t *testing.T) {
+	clientset := fake.NewClientset(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "settings"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "credentials"}},
+	)
+	tests := []struct {
+		name string
+		spec string
+		// Part of the denial message, empty if allowed
+		wantDenied   string
+		wantWarnings []string
+	}{
+		{
+			name: "valid",
+			spec: `{"image": "nginx:1.27", "ports": [{"hostPort": 80, "containerPort": 8080}],
+				"volumes": [{"mountPath": "/etc/app", "configMap": {"name": "settings"}}]}`,
+		},
+		{
+			name: "duplicate hostPort",
+			spec: `{"image": "nginx:1.27", "ports": [{"hostPort": 80, "containerPort": 8080},
+				{"hostPort": 80, "containerPort": 8081, "protocol": "TCP"}]}`,
+			wantDenied: "ports[1]: duplicate hostPort 80/TCP",
+		},
+		{
+			name: "same hostPort over UDP",
+			spec: `{"image": "coredns:1.12", "ports": [{"hostPort": 53, "containerPort": 53},
+				{"hostPort": 53, "containerPort": 53, "protocol": "UDP"}]}`,
+		},
+		{
+			name: "port names equal once truncated",
+			spec: `{"image": "nginx:1.27", "ports": [{"name": "metrics-exporter-a", "hostPort": 9100, "containerPort": 9100},
+				{"name": "metrics-exporter-b", "hostPort": 9101, "containerPort": 9101}]}`,
+			wantDenied: "ports[1]: name metrics-exporter-b collides with metrics-exporter-a",
+		},
+		{
+			name:       "Service type not allowed",
+			spec:       `{"image": "nginx:1.27", "serviceType": "ExternalName", "ports": [{"hostPort": 80, "containerPort": 8080}]}`,
+			wantDenied: "serviceType: ExternalName is not allowed",
+		},
+		{
+			name:       "volume with two types",
+			spec:       `{"image": "nginx:1.27", "volumes": [{"mountPath": "/data", "emptyDir": {}, "secret": {"name": "credentials"}}]}`,
+			wantDenied: "volumes[0]: volume for path /data must have exactly one type",
+		},
+		{
+			name:       "DaemonSet with replicas",
+			spec:       `{"image": "fluent-bit:4.0", "workloadType": "DaemonSet", "replicas": 3}`,
+			wantDenied: "replicas: only supported with workloadType Deployment or StatefulSet, not DaemonSet",
+		},
+		{
+			name: "Job with the default replicas",
+			spec: `{"image": "busybox:1.37", "workloadType": "Job", "replicas": 1}`,
+		},
+		{
+			name: "StatefulSet with replicas",
+			spec: `{"image": "postgres:17", "workloadType": "StatefulSet", "replicas": 3}`,
+		},
+		{
+			name:       "unknown workload type",
+			spec:       `{"image": "nginx:1.27", "workloadType": "ReplicaSet"}`,
+			wantDenied: "unknown workloadType ReplicaSet",
+		},
+		{
+			name: "missing references",
+			spec: `{"image": "nginx:1.27",
+				"volumes": [{"mountPath": "/etc/app", "configMap": {"name": "absent"}},
+					{"mountPath": "/etc/extra", "secret": {"name": "optional", "optional": true}}],
+				"env": [{"name": "TOKEN", "valueFrom": {"secretKeyRef": {"name": "tokens", "key": "token"}}}]}`,
+			wantWarnings: []string{"ConfigMap absent not found", "Secret tokens not found"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			object := `{"apiVersion": "apps.raulpedroche.es/v1alpha1", "kind": "SimpleApp",
+				"metadata": {"name": "test"}, "spec": ` + tt.spec + `}`
+			request := &admissionv1.AdmissionRequest{
+				Namespace: "default",
+				Operation: admissionv1.Create,
+				Object:    runtime.RawExtension{Raw: []byte(object)},
+			}
+			response := validateAdmission(clientset, request)
+			if tt.wantDenied == "" {
+				if !response.Allowed {
+					t.Errorf("denied with %v", response.Result.Message)
+				}
+			} else if response.Allowed {
+				t.Errorf("allowed, want denied with %v", tt.wantDenied)
+			} else if !strings.Contains(response.Result.Message, tt.wantDenied) {
+				t.Errorf("denied with %v, want %v", response.Result.Message, tt.wantDenied)
+			}
+			if len(response.Warnings) > 0 || len(tt.wantWarnings) > 0 {
+				if !reflect.DeepEqual(response.Warnings, tt.wantWarnings) {
+					t.Errorf("warnings = %q, want %q", response.Warnings, tt.wantWarnings)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// Name of the webhook configurations whose caBundle is filled in when
+	// serving with a self-signed certificate
+	webhookConfigurationName = "simpleapp"
+)
+
+// Webhook server options, set from the command line. The webhooks are only
+// served when given an address, which is required while the CRD uses the
+// conversion webhook. Without a certificate a self-signed one is
+// generated for the webhook Service on startup.
+var (
+	webhookAddr     = ""
+	webhookService  = "simpleapp-webhook"
+	webhookCertFile = ""
+	webhookKeyFile  = ""
+)
+
+// Starts serving the admission webhooks in the background
+func startWebhookServer(clientset *kubernetes.Clientset, namespace string) error {
+	var certificate tls.Certificate
+	var err error
+	if webhookCertFile != "" || webhookKeyFile != "" {
+		certificate, err = tls.LoadX509KeyPair(webhookCertFile, webhookKeyFile)
+		if err != nil {
+			return err
+		}
+	} else {
+		var caBundle []byte
+		certificate, caBundle, err = selfSignedCertificate(webhookService + "." + namespace + ".svc")
+		if err != nil {
+			return err
+		}
+		err = injectCABundle(clientset, caBundle)
+		if err != nil {
+			return err
+		}
+		err = injectConversionCABundle(clientset, caBundle)
+		if err != nil {
+			return err
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/convert", serveConversion)
+	mux.HandleFunc("/mutate", func(w http.ResponseWriter, r *http.Request) {
+		serveAdmission(w, r, mutateAdmission)
+	})
+	mux.HandleFunc("/validate", func(w http.ResponseWriter, r *http.Request) {
+		serveAdmission(w, r, func(request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+			return validateAdmission(clientset, request)
+		})
+	})
+	server := &http.Server{
+		Addr:      webhookAddr,
+		Handler:   mux,
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{certificate}},
+	}
+	go func() {
+		log.Fatal(server.ListenAndServeTLS("", ""))
+	}()
+	log.Printf("Serving admission webhooks on %v", webhookAddr)
+	return nil
+}
+
+// Generates a self-signed certificate for dnsName, returning it along with its
+// PEM encoding to be used as caBundle
+func selfSignedCertificate(dnsName string) (tls.Certificate, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	template := x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: dnsName},
+		DNSNames:              []string{dnsName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	return certificate, certPEM, nil
+}
+
+// Makes the API server trust our self-signed certificate
+func injectCABundle(clientset *kubernetes.Clientset, caBundle []byte) error {
+	mutatingConfiguration, err := clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(context.TODO(), webhookConfigurationName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	changed := false
+	for i := range mutatingConfiguration.Webhooks {
+		if !bytes.Equal(mutatingConfiguration.Webhooks[i].ClientConfig.CABundle, caBundle) {
+			mutatingConfiguration.Webhooks[i].ClientConfig.CABundle = caBundle
+			changed = true
+		}
+	}
+	if changed {
+		_, err = clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().Update(context.TODO(), mutatingConfiguration, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		log.Printf("Updated caBundle of MutatingWebhookConfiguration %v", webhookConfigurationName)
+	}
+
+	validatingConfiguration, err := clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(context.TODO(), webhookConfigurationName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	changed = false
+	for i := range validatingConfiguration.Webhooks {
+		if !bytes.Equal(validatingConfiguration.Webhooks[i].ClientConfig.CABundle, caBundle) {
+			validatingConfiguration.Webhooks[i].ClientConfig.CABundle = caBundle
+			changed = true
+		}
+	}
+	if changed {
+		_, err = clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().Update(context.TODO(), validatingConfiguration, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		log.Printf("Updated caBundle of ValidatingWebhookConfiguration %v", webhookConfigurationName)
+	}
+	return nil
+}
+
+// Decodes an AdmissionReview, passes its request to admit and writes back the
+// response
+func serveAdmission(w http.ResponseWriter, r *http.Request, admit func(*admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var review admissionv1.AdmissionReview
+	err = json.Unmarshal(body, &review)
+	if err != nil || review.Request == nil {
+		http.Error(w, "expected an AdmissionReview with a request", http.StatusBadRequest)
+		return
+	}
+
+	response := admit(review.Request)
+	response.UID = review.Request.UID
+	review.Request = nil
+	review.Response = response
+	payload, err := json.Marshal(review)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(payload)
+}
+
+func denyAdmission(message string) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusUnprocessableEntity,
+			Reason:  metav1.StatusReasonInvalid,
+			Message: message,
+		},
+	}
+}
+
+// Decodes the SimpleApp of an admission request. Its namespace is only set in
+// the request when it is being created.
+func admissionSimpleApp(request *admissionv1.AdmissionRequest) (SimpleApp, error) {
+	var sa SimpleApp
+	err := json.Unmarshal(request.Object.Raw, &sa)
+	if err != nil {
+		return SimpleApp{}, fmt.Errorf("cannot decode SimpleApp: %v", err)
+	}
+	if sa.Namespace == "" {
+		sa.Namespace = request.Namespace
+	}
+	return sa, nil
+}
+
+func mutateAdmission(request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	sa, err := admissionSimpleApp(request)
+	if err != nil {
+		return denyAdmission(err.Error())
+	}
+	patch := sa.defaultingPatch()
+	if len(patch) == 0 {
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+	payload, err := json.Marshal(patch)
+	if err != nil {
+		return denyAdmission(err.Error())
+	}
+	patchType := admissionv1.PatchTypeJSONPatch
+	return &admissionv1.AdmissionResponse{Allowed: true, Patch: payload, PatchType: &patchType}
+}
+
+func validateAdmission(clientset kubernetes.Interface, request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	sa, err := admissionSimpleApp(request)
+	if err != nil {
+		return denyAdmission(err.Error())
+	}
+	errs, warnings := sa.validate(clientset)
+	if len(errs) > 0 {
+		response := denyAdmission(fmt.Sprintf("SimpleApp %v is invalid: %v", sa.Name, strings.Join(errs, "; ")))
+		response.Warnings = warnings
+		return response
+	}
+	return &admissionv1.AdmissionResponse{Allowed: true, Warnings: warnings}
+}